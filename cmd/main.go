@@ -2,15 +2,23 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"passportier-bot/internal/api"
 	"passportier-bot/internal/bot"
-	"passportier-bot/internal/models"
+	"passportier-bot/internal/reminder"
 	"passportier-bot/internal/security"
 	"passportier-bot/internal/storage"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -22,9 +30,19 @@ func main() {
 	// Initialize Database
 	db := storage.InitDB()
 
-	// Run migrations
-	if err := db.AutoMigrate(&models.User{}, &models.PasswordEntry{}); err != nil {
-		log.Printf("Migration Failed: %v", err)
+	// Migration subcommand: passportier migrate [up|down [n]|status]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(db, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// Apply pending migrations
+	if applied, err := storage.MigrateUp(db); err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	} else if len(applied) > 0 {
+		log.Printf("Applied %d migration(s)", len(applied))
 	}
 
 	// Initialize Redis and SessionManager
@@ -33,27 +51,130 @@ func main() {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
 	sessionManager := security.NewSessionManager(redisClient)
+	attemptLimiter := security.NewAttemptLimiter(redisClient)
+
+	// Webhook mode when WEBHOOK_URL is set, long polling otherwise
+	webhook, err := bot.WebhookFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid webhook configuration: %v", err)
+	}
 
 	// Initialize and start bot
-	b, err := bot.New(db, sessionManager)
+	b, err := bot.New(db, sessionManager, attemptLimiter, webhook)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Remove webhook before polling
-	if err := b.RemoveWebhook(); err != nil {
+	if webhook != nil {
+		if err := webhook.Register(b); err != nil {
+			log.Fatalf("Failed to set webhook: %v", err)
+		}
+		log.Println("Receiving updates via webhook")
+	} else if err := b.RemoveWebhook(); err != nil {
+		// Remove webhook before polling
 		log.Printf("Warning: Failed to remove webhook: %v", err)
 	}
 
+	// Stop everything on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Rotation reminders read plaintext metadata only, no vault access
+	go reminder.NewScheduler(db, bot.Notifier(b)).Run(ctx)
+
 	log.Println("PassPortierBot is running...")
-	
+
 	// Start API server for Web App
 	apiServer := api.NewServer(db, sessionManager)
+	if webhook != nil {
+		apiServer.Mount(webhook.Path(), webhook)
+	}
 	go func() {
 		if err := apiServer.Start(":8080"); err != nil {
 			log.Printf("API server error: %v", err)
 		}
 	}()
-	
-	b.Start()
+
+	go b.Start()
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
+	shutdown(b, apiServer)
+
+	if err := redisClient.Close(); err != nil {
+		log.Printf("Warning: Failed to close Redis: %v", err)
+	}
+	log.Println("PassPortierBot stopped")
+}
+
+// shutdownTimeout bounds how long in-flight requests may take to finish.
+const shutdownTimeout = 15 * time.Second
+
+// shutdown stops the poller and the API server, letting in-flight updates and
+// requests complete.
+func shutdown(b *telebot.Bot, apiServer *api.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.Stop()
+		close(done)
+	}()
+
+	if err := apiServer.Shutdown(ctx); err != nil {
+		log.Printf("Warning: API server shutdown: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("Warning: Bot poller did not stop within %s", shutdownTimeout)
+	}
+}
+
+// runMigrate executes the migrate subcommand.
+func runMigrate(db *gorm.DB, args []string) error {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		applied, err := storage.MigrateUp(db)
+		for _, m := range applied {
+			log.Printf("Applied %03d_%s", m.Version, m.Name)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := storage.MigrateDown(db, steps)
+		for _, m := range reverted {
+			log.Printf("Reverted %03d_%s", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		states, err := storage.MigrationStatus(db)
+		if err != nil {
+			return err
+		}
+		for _, st := range states {
+			status := "pending"
+			if st.AppliedAt != nil {
+				status = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%03d_%s\t%s\n", st.Version, st.Name, status)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q (use up, down [n] or status)", cmd)
+	}
 }
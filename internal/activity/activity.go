@@ -0,0 +1,69 @@
+// Package activity records vault access events in the append-only
+// audit_events table and reads them back for /activity and /api/activity.
+package activity
+
+import (
+	"log"
+
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Event names.
+const (
+	EventUnlock          = "unlock"
+	EventUnlockFailed    = "unlock_failed"
+	EventLock            = "lock"
+	EventGet             = "get"
+	EventList            = "list"
+	EventInlineQuery     = "inline_query" // Entries decrypted into an inline answer
+	EventInlineReveal    = "inline_reveal"
+	EventRead            = "read"
+	EventUpdate          = "update"
+	EventDelete          = "delete"
+	EventExport          = "export"
+	EventShare           = "share"
+	EventEmergencyAccess = "emergency_access" // A trusted contact opened the vault
+)
+
+// Event sources.
+const (
+	SourceChat   = "chat"   // Bot command or message
+	SourceInline = "inline" // Inline query result
+	SourceWebApp = "webapp" // Mini App data sent through the bot
+	SourceAPI    = "api"    // Mini App HTTP API
+)
+
+// Listing limits for Recent.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Record appends an event. Logging must never break the action being
+// logged, so failures are only written to the server log.
+func Record(db *gorm.DB, userID int64, event, source, service string) {
+	e := models.AuditEvent{
+		UserID:  userID,
+		Event:   event,
+		Source:  source,
+		Service: service,
+	}
+	if err := db.Create(&e).Error; err != nil {
+		log.Printf("[AUDIT] Failed to record %s for user %d: %v", event, userID, err)
+	}
+}
+
+// Recent returns the user's latest events, newest first. limit is clamped
+// to 1..MaxLimit; non-positive values mean DefaultLimit.
+func Recent(db *gorm.DB, userID int64, limit int) ([]models.AuditEvent, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	var events []models.AuditEvent
+	err := db.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
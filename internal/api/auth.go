@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	initDataHeader = "X-Telegram-Init-Data"
+	initDataMaxAge = 24 * time.Hour // Signed initData older than this is rejected
+)
+
+// Predefined initData validation errors
+var (
+	ErrInitDataMissing = errors.New("init data missing")
+	ErrInitDataInvalid = errors.New("init data signature invalid")
+	ErrInitDataExpired = errors.New("init data expired")
+)
+
+type ctxKey int
+
+const userIDKey ctxKey = iota
+
+// authMiddleware verifies Telegram WebApp initData and injects the signed user ID.
+// Request parameters are never trusted for identity.
+func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := verifyInitData(r.Header.Get(initDataHeader), s.botToken, time.Now())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIDKey, userID)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// userIDFromContext returns the Telegram user ID set by authMiddleware.
+func userIDFromContext(ctx context.Context) int64 {
+	userID, _ := ctx.Value(userIDKey).(int64)
+	return userID
+}
+
+// verifyInitData validates initData per Telegram's WebApp scheme:
+// secret = HMAC-SHA256("WebAppData", botToken), hash = HMAC-SHA256(secret, dataCheckString).
+// Returns the user ID from the signed "user" field.
+func verifyInitData(initData, botToken string, now time.Time) (int64, error) {
+	if initData == "" || botToken == "" {
+		return 0, ErrInitDataMissing
+	}
+
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return 0, ErrInitDataInvalid
+	}
+
+	receivedHash := values.Get("hash")
+	if receivedHash == "" {
+		return 0, ErrInitDataInvalid
+	}
+
+	expected := signInitData(values, botToken)
+	if !hmac.Equal([]byte(expected), []byte(receivedHash)) {
+		return 0, ErrInitDataInvalid
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return 0, ErrInitDataInvalid
+	}
+	if now.Sub(time.Unix(authDate, 0)) > initDataMaxAge {
+		return 0, ErrInitDataExpired
+	}
+
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.ID == 0 {
+		return 0, ErrInitDataInvalid
+	}
+
+	return user.ID, nil
+}
+
+// signInitData computes the hex HMAC of the data-check-string (all fields except hash).
+func signInitData(values url.Values, botToken string) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if k != "hash" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+values.Get(k))
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
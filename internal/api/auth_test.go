@@ -0,0 +1,126 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:TEST-token"
+
+// signedInitData builds initData signed the way Telegram does it, independently
+// of signInitData, so a mistake there cannot cancel out here.
+func signedInitData(fields map[string]string, botToken string) url.Values {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	values := url.Values{}
+	for _, k := range keys {
+		lines = append(lines, k+"="+fields[k])
+		values.Set(k, fields[k])
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	values.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return values
+}
+
+func TestVerifyInitData(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	fresh := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-initDataMaxAge-time.Minute).Unix(), 10)
+	user := `{"id":42,"first_name":"Ali"}`
+
+	valid := func() url.Values {
+		return signedInitData(map[string]string{"auth_date": fresh, "query_id": "AAE", "user": user}, testBotToken)
+	}
+
+	tests := []struct {
+		name     string
+		initData func() string
+		token    string
+		wantID   int64
+		wantErr  error
+	}{
+		{
+			name:     "valid signature",
+			initData: func() string { return valid().Encode() },
+			token:    testBotToken,
+			wantID:   42,
+		},
+		{
+			name: "tampered field",
+			initData: func() string {
+				v := valid()
+				v.Set("user", `{"id":43,"first_name":"Ali"}`)
+				return v.Encode()
+			},
+			token:   testBotToken,
+			wantErr: ErrInitDataInvalid,
+		},
+		{
+			name:     "wrong bot token",
+			initData: func() string { return valid().Encode() },
+			token:    "654321:OTHER-token",
+			wantErr:  ErrInitDataInvalid,
+		},
+		{
+			name: "missing hash",
+			initData: func() string {
+				v := valid()
+				v.Del("hash")
+				return v.Encode()
+			},
+			token:   testBotToken,
+			wantErr: ErrInitDataInvalid,
+		},
+		{
+			name: "expired auth_date",
+			initData: func() string {
+				return signedInitData(map[string]string{"auth_date": stale, "user": user}, testBotToken).Encode()
+			},
+			token:   testBotToken,
+			wantErr: ErrInitDataExpired,
+		},
+		{
+			name: "missing user",
+			initData: func() string {
+				return signedInitData(map[string]string{"auth_date": fresh, "query_id": "AAE"}, testBotToken).Encode()
+			},
+			token:   testBotToken,
+			wantErr: ErrInitDataInvalid,
+		},
+		{
+			name:     "empty init data",
+			initData: func() string { return "" },
+			token:    testBotToken,
+			wantErr:  ErrInitDataMissing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := verifyInitData(tt.initData(), tt.token, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if id != tt.wantID {
+				t.Fatalf("id = %d, want %d", id, tt.wantID)
+			}
+		})
+	}
+}
@@ -2,38 +2,44 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
-
-	"passportier-bot/internal/crypto"
+	"strings"
+	"time"
+
+	"passportier-bot/internal/activity"
+	"passportier-bot/internal/generator"
+	"passportier-bot/internal/health"
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/search"
+	"passportier-bot/internal/services"
+	"passportier-bot/internal/totp"
 	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
 )
 
-// handlePasswords returns user's passwords.
+// handlePasswords returns user's passwords, favorites first.
+// Optional ?folder= and ?tag= query parameters filter the list; ?vault= picks the vault.
 func (s *Server) handlePasswords(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Get user ID from query (for now, simple approach)
-	// In production, validate Telegram initData
-	userIDStr := r.URL.Query().Get("user_id")
-	if userIDStr == "" {
-		http.Error(w, "user_id required", http.StatusBadRequest)
-		return
-	}
+	// User ID comes from verified Telegram initData
+	userID := userIDFromContext(r.Context())
 
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "invalid user_id", http.StatusBadRequest)
+	// Check if session is active
+	scope, userKey, _, err := s.scopeFor(r, userID)
+	if errors.Is(err, vault.ErrNotMember) {
+		http.Error(w, "Not a vault member", http.StatusForbidden)
 		return
 	}
-
-	// Check if session is active
-	userKey, err := s.sm.GetSession(context.Background(), userID)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -44,27 +50,31 @@ func (s *Server) handlePasswords(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get passwords
-	entries, err := vault.ListEntries(s.db, userID)
+	all, err := vault.ListCredentials(s.db, scope, userKey, vault.Filter{})
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	// Decrypt and build response
-	cm := crypto.NewCryptoManager()
-	var passwords []PasswordResponse
+	filter := vault.Filter{
+		Folder: r.URL.Query().Get("folder"),
+		Tag:    r.URL.Query().Get("tag"),
+	}
 
-	for _, entry := range entries {
-		decrypted, err := cm.Decrypt(entry.EncryptedData, userKey)
-		if err != nil {
+	// Decrypted entries matching the filter
+	var passwords []PasswordResponse
+	for i := range all {
+		if !all[i].Decrypted || !filter.Match(all[i].Credential) {
 			continue
 		}
+		passwords = append(passwords, newPasswordResponse(&all[i].Entry, all[i].Credential))
+	}
 
-		passwords = append(passwords, PasswordResponse{
-			ID:      entry.ID,
-			Service: entry.Service,
-			Data:    decrypted,
-		})
+	activity.Record(s.db, userID, activity.EventList, activity.SourceAPI, "")
+
+	folders := []string{}
+	for _, f := range vault.Folders(all) {
+		folders = append(folders, f.Name)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -72,6 +82,7 @@ func (s *Server) handlePasswords(w http.ResponseWriter, r *http.Request) {
 		"success":   true,
 		"passwords": passwords,
 		"count":     len(passwords),
+		"folders":   folders,
 	})
 }
 
@@ -83,7 +94,6 @@ func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		UserID  int64  `json:"user_id"`
 		Service string `json:"service"`
 	}
 
@@ -92,18 +102,30 @@ func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check session
-	if _, err := s.sm.GetSession(context.Background(), req.UserID); err != nil {
+	userID := userIDFromContext(r.Context())
+
+	// Check session and write access
+	scope, _, role, err := s.scopeFor(r, userID)
+	if errors.Is(err, vault.ErrNotMember) {
+		http.Error(w, "Not a vault member", http.StatusForbidden)
+		return
+	}
+	if err != nil {
 		http.Error(w, "Session locked", http.StatusUnauthorized)
 		return
 	}
+	if !vault.CanWrite(role) {
+		http.Error(w, "Read-only vault", http.StatusForbidden)
+		return
+	}
 
 	// Delete entry
-	if err := vault.DeleteEntry(s.db, req.UserID, req.Service); err != nil {
+	if err := vault.DeleteEntry(s.db, scope, req.Service); err != nil {
 		log.Printf("Delete error: %v", err)
 		http.Error(w, "Delete failed", http.StatusInternalServerError)
 		return
 	}
+	activity.Record(s.db, userID, activity.EventDelete, activity.SourceAPI, req.Service)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]bool{"success": true})
@@ -116,21 +138,19 @@ func (s *Server) handleGetOne(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDStr := r.URL.Query().Get("user_id")
+	userID := userIDFromContext(r.Context())
 	service := r.URL.Query().Get("service")
 
-	if userIDStr == "" || service == "" {
-		http.Error(w, "user_id and service required", http.StatusBadRequest)
+	if service == "" {
+		http.Error(w, "service required", http.StatusBadRequest)
 		return
 	}
 
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "invalid user_id", http.StatusBadRequest)
+	scope, userKey, _, err := s.scopeFor(r, userID)
+	if errors.Is(err, vault.ErrNotMember) {
+		http.Error(w, "Not a vault member", http.StatusForbidden)
 		return
 	}
-
-	userKey, err := s.sm.GetSession(context.Background(), userID)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -139,25 +159,84 @@ func (s *Server) handleGetOne(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	entry, err := vault.GetEntry(s.db, userID, service)
+	entry, err := vault.GetEntry(s.db, scope, service)
 	if err != nil {
 		http.Error(w, "Not found", http.StatusNotFound)
 		return
 	}
 
-	cm := crypto.NewCryptoManager()
-	decrypted, err := cm.Decrypt(entry.EncryptedData, userKey)
+	cred, err := vault.DecryptCredential(entry, userKey)
 	if err != nil {
 		http.Error(w, "Decrypt error", http.StatusInternalServerError)
 		return
 	}
 
+	activity.Record(s.db, userID, activity.EventRead, activity.SourceAPI, entry.Service)
+
+	resp := newPasswordResponse(entry, cred)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"id":      entry.ID,
-		"service": entry.Service,
-		"data":    decrypted,
+		"success":    true,
+		"id":         resp.ID,
+		"service":    resp.Service,
+		"data":       resp.Data,
+		"credential": resp.Credential,
+		"favorite":   resp.Favorite,
+		"expires_at": resp.ExpiresAt,
+	})
+}
+
+// handleSearch ranks the user's service names against ?q=.
+// best is set only when one entry matches unambiguously.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+	query := r.URL.Query().Get("q")
+
+	if query == "" {
+		http.Error(w, "q required", http.StatusBadRequest)
+		return
+	}
+
+	scope, _, _, err := s.scopeFor(r, userID)
+	if errors.Is(err, vault.ErrNotMember) {
+		http.Error(w, "Not a vault member", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "session_locked",
+		})
+		return
+	}
+
+	matches, err := vault.SearchEntries(s.db, scope, query)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]SearchResult, 0, len(matches))
+	for _, m := range matches {
+		results = append(results, SearchResult{Service: m.Name, Kind: m.Kind.String(), Score: m.Score})
+	}
+
+	best := ""
+	if m, ok := search.Best(matches); ok {
+		best = m.Name
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"results":   results,
+		"best":      best,
+		"ambiguous": best == "" && len(results) > 1,
 	})
 }
 
@@ -169,36 +248,597 @@ func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		UserID     int64  `json:"user_id"`
-		OldService string `json:"old_service"`
-		NewService string `json:"new_service"`
-		Data       string `json:"data"`
+		OldService string             `json:"old_service"`
+		NewService string             `json:"new_service"`
+		Data       string             `json:"data"` // Legacy free-text form
+		Credential *models.Credential `json:"credential"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
+	if strings.TrimSpace(req.NewService) == "" {
+		http.Error(w, "new_service required", http.StatusBadRequest)
+		return
+	}
 
-	userKey, err := s.sm.GetSession(context.Background(), req.UserID)
+	userID := userIDFromContext(r.Context())
+
+	scope, userKey, role, err := s.scopeFor(r, userID)
+	if errors.Is(err, vault.ErrNotMember) {
+		http.Error(w, "Not a vault member", http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Session locked", http.StatusUnauthorized)
 		return
 	}
+	if !vault.CanWrite(role) {
+		http.Error(w, "Read-only vault", http.StatusForbidden)
+		return
+	}
 
-	// Delete old entry if service name changed
-	if req.OldService != req.NewService {
-		if err := vault.DeleteEntry(s.db, req.UserID, req.OldService); err != nil {
-			log.Printf("Delete old entry error: %v", err)
-		}
+	cred := vault.ParseCredential(req.Data)
+	if req.Credential != nil {
+		cred = *req.Credential
 	}
 
-	// Upsert with new data (UpsertCredential handles encryption internally)
-	if err := vault.UpsertCredential(s.db, req.UserID, req.NewService, req.Data, userKey); err != nil {
+	// Rename (keeping the old state in history) and save in one transaction
+	err = vault.UpdateCredential(s.db, scope, req.OldService, req.NewService, cred, userKey)
+	switch {
+	case err == nil:
+	case errors.Is(err, vault.ErrServiceExists):
+		http.Error(w, "Service already exists", http.StatusConflict)
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Entry not found", http.StatusNotFound)
+		return
+	case errors.Is(err, totp.ErrInvalidSecret):
+		http.Error(w, "Invalid TOTP secret", http.StatusBadRequest)
+		return
+	default:
+		log.Printf("Update entry error: %v", err)
 		http.Error(w, "Save error", http.StatusInternalServerError)
 		return
 	}
+	activity.Record(s.db, userID, activity.EventUpdate, activity.SourceAPI, req.NewService)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// handleFavorite pins or unpins an entry.
+func (s *Server) handleFavorite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Service  string `json:"service"`
+		Favorite bool   `json:"favorite"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Service == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+
+	scope, _, role, err := s.scopeFor(r, userID)
+	if errors.Is(err, vault.ErrNotMember) {
+		http.Error(w, "Not a vault member", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Session locked", http.StatusUnauthorized)
+		return
+	}
+	if !vault.CanWrite(role) {
+		http.Error(w, "Read-only vault", http.StatusForbidden)
+		return
+	}
+
+	if err := vault.SetFavorite(s.db, scope, req.Service, req.Favorite); err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]bool{"success": true})
 }
+
+// handleAudit checks the user's passwords against the breach store.
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+
+	if _, err := s.sm.GetSession(context.Background(), userID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "session_locked",
+		})
+		return
+	}
+
+	report, err := services.AuditBreaches(r.Context(), s.db, s.sm, userID, s.breaches)
+	if err != nil {
+		log.Printf("Breach audit error: %v", err)
+		http.Error(w, "Audit failed", http.StatusBadGateway)
+		return
+	}
+
+	breached := make([]BreachResponse, 0, len(report.Breached))
+	for _, e := range report.Breached {
+		breached = append(breached, BreachResponse{Service: e.Service, Count: e.Count})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"checked":       report.Checked,
+		"breached":      breached,
+		"undecryptable": report.Undecryptable,
+	})
+}
+
+// handleHealth reports weak, reused, stale and undecryptable entries.
+// ?months= sets the stale threshold (default health.DefaultStaleMonths).
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+
+	staleMonths := health.DefaultStaleMonths
+	if v := r.URL.Query().Get("months"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid months", http.StatusBadRequest)
+			return
+		}
+		staleMonths = n
+	}
+
+	report, err := services.VaultHealth(r.Context(), s.db, s.sm, userID, staleMonths)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "session_locked",
+		})
+		return
+	}
+
+	resp := HealthResponse{
+		Total:         report.Total,
+		Weak:          []WeakResponse{},
+		Reused:        report.Reused,
+		Stale:         []StaleResponse{},
+		Undecryptable: report.Undecryptable,
+		StaleMonths:   staleMonths,
+	}
+	for _, e := range report.Weak {
+		resp.Weak = append(resp.Weak, WeakResponse{Service: e.Service, Score: e.Score, Feedback: e.Feedback})
+	}
+	for _, e := range report.Stale {
+		resp.Stale = append(resp.Stale, StaleResponse{Service: e.Service, PasswordChangedAt: e.ChangedAt})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"health":  resp,
+	})
+}
+
+// handleShare returns the ciphertext of a share link and counts the view.
+// The decryption key never reaches the server (it is in the URL fragment).
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/share/")
+	if id == "" || strings.Contains(id, "/") {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+
+	ciphertext, viewsLeft, err := vault.OpenShare(s.db, id, time.Now())
+	if err != nil {
+		if !errors.Is(err, vault.ErrShareNotFound) {
+			log.Printf("Share open error: %v", err)
+		}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "not_found",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"ciphertext": base64.StdEncoding.EncodeToString(ciphertext),
+		"views_left": viewsLeft,
+	})
+}
+
+// handleTOTP returns the current 2FA code for an entry.
+func (s *Server) handleTOTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+	service := r.URL.Query().Get("service")
+
+	if service == "" {
+		http.Error(w, "service required", http.StatusBadRequest)
+		return
+	}
+
+	scope, userKey, _, err := s.scopeFor(r, userID)
+	if errors.Is(err, vault.ErrNotMember) {
+		http.Error(w, "Not a vault member", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "session_locked",
+		})
+		return
+	}
+
+	entry, err := vault.GetEntry(s.db, scope, service)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	cred, err := vault.DecryptCredential(entry, userKey)
+	if err != nil {
+		http.Error(w, "Decrypt error", http.StatusInternalServerError)
+		return
+	}
+
+	if cred.TOTP == "" {
+		http.Error(w, "No TOTP secret", http.StatusNotFound)
+		return
+	}
+	activity.Record(s.db, userID, activity.EventRead, activity.SourceAPI, entry.Service)
+
+	code, err := totp.Now(cred.TOTP)
+	if err != nil {
+		http.Error(w, "Invalid TOTP secret", http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"service":   entry.Service,
+		"code":      code.Code,
+		"remaining": code.Remaining,
+		"period":    code.Period,
+	})
+}
+
+// handleGenerate creates a secure password, optionally saving it to an entry.
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Length           int    `json:"length"`
+		Lower            *bool  `json:"lower"`
+		Upper            *bool  `json:"upper"`
+		Digits           *bool  `json:"digits"`
+		Symbols          *bool  `json:"symbols"`
+		ExcludeAmbiguous bool   `json:"exclude_ambiguous"`
+		Words            int    `json:"words"`
+		Separator        string `json:"separator"`
+		SaveTo           string `json:"save_to"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	opts := generator.DefaultOptions()
+	if req.Length > 0 {
+		opts.Length = req.Length
+	}
+	for _, o := range []struct {
+		set *bool
+		dst *bool
+	}{
+		{req.Lower, &opts.Lower},
+		{req.Upper, &opts.Upper},
+		{req.Digits, &opts.Digits},
+		{req.Symbols, &opts.Symbols},
+	} {
+		if o.set != nil {
+			*o.dst = *o.set
+		}
+	}
+	opts.ExcludeAmbiguous = req.ExcludeAmbiguous
+	if req.Words != 0 && (req.Words < generator.MinWords || req.Words > generator.MaxWords) {
+		http.Error(w, generator.ErrInvalidWords.Error(), http.StatusBadRequest)
+		return
+	}
+	opts.Words = req.Words
+	opts.Separator = req.Separator
+
+	password, err := generator.Generate(opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.SaveTo != "" {
+		userID := userIDFromContext(r.Context())
+		if err := services.SetServicePassword(context.Background(), s.db, s.sm, userID, req.SaveTo, password); err != nil {
+			log.Printf("Generate save error: %v", err)
+			http.Error(w, "Save error", http.StatusUnauthorized)
+			return
+		}
+		activity.Record(s.db, userID, activity.EventUpdate, activity.SourceAPI, req.SaveTo)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"password": password,
+		"entropy":  generator.Entropy(opts),
+		"saved":    req.SaveTo != "",
+	})
+}
+
+// HistoryResponse represents a past version of an entry for API response.
+type HistoryResponse struct {
+	VersionID  uint              `json:"version_id"`
+	Service    string            `json:"service"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Credential models.Credential `json:"credential"`
+	Decrypted  bool              `json:"decrypted"`
+}
+
+// handleHistory returns past versions of an entry.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+	service := r.URL.Query().Get("service")
+
+	if service == "" {
+		http.Error(w, "service required", http.StatusBadRequest)
+		return
+	}
+
+	scope, key, _, err := s.scopeFor(r, userID)
+	if errors.Is(err, vault.ErrNotMember) {
+		http.Error(w, "Not a vault member", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Session locked", http.StatusUnauthorized)
+		return
+	}
+
+	entry, versions, err := vault.EntryHistory(s.db, scope, service)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	items := services.DecryptHistory(versions, key)
+
+	activity.Record(s.db, userID, activity.EventRead, activity.SourceAPI, entry.Service)
+
+	history := make([]HistoryResponse, 0, len(items))
+	for _, item := range items {
+		history = append(history, HistoryResponse{
+			VersionID:  item.VersionID,
+			Service:    item.Service,
+			CreatedAt:  item.CreatedAt,
+			Credential: item.Credential,
+			Decrypted:  item.Decrypted,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"service": entry.Service,
+		"history": history,
+	})
+}
+
+// handleRestore restores a past version of an entry.
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		VersionID uint `json:"version_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.VersionID == 0 {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+
+	scope, userKey, role, err := s.scopeFor(r, userID)
+	if errors.Is(err, vault.ErrNotMember) {
+		http.Error(w, "Not a vault member", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Session locked", http.StatusUnauthorized)
+		return
+	}
+	if !vault.CanWrite(role) {
+		http.Error(w, "Read-only vault", http.StatusForbidden)
+		return
+	}
+
+	entry, err := vault.RestoreVersion(s.db, scope, req.VersionID, userKey)
+	if errors.Is(err, vault.ErrVersionUnreadable) {
+		http.Error(w, "Version cannot be restored", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Restore error: %v", err)
+		http.Error(w, "Restore failed", http.StatusNotFound)
+		return
+	}
+	activity.Record(s.db, userID, activity.EventUpdate, activity.SourceAPI, entry.Service)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"service": entry.Service,
+	})
+}
+
+// newPasswordResponse builds the API view of a decrypted entry.
+func newPasswordResponse(entry *models.PasswordEntry, cred models.Credential) PasswordResponse {
+	return PasswordResponse{
+		ID:         entry.ID,
+		Service:    entry.Service,
+		Data:       vault.FormatCredential(cred),
+		Credential: cred,
+		Favorite:   entry.Favorite,
+		ExpiresAt:  entry.ExpiresAt,
+		Rotation:   entry.RotationDays,
+	}
+}
+
+// handleVaults lists the user's team vaults and the active one (0 = personal).
+// No session is needed: only names and roles are returned.
+func (s *Server) handleVaults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+
+	vaults, err := vault.UserVaults(s.db, userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	active, _, err := vault.ActiveVault(s.db, userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]VaultResponse, 0, len(vaults))
+	for _, v := range vaults {
+		resp = append(resp, VaultResponse{ID: v.Vault.ID, Name: v.Vault.Name, Role: v.Role})
+	}
+	var activeID uint
+	if active != nil {
+		activeID = active.ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"vaults":  resp,
+		"active":  activeID,
+	})
+}
+
+// scopeFor resolves the vault a request works on: ?vault=<id> when given
+// (0 = personal), otherwise the user's active vault.
+func (s *Server) scopeFor(r *http.Request, userID int64) (vault.Scope, string, string, error) {
+	param := r.URL.Query().Get("vault")
+	if param == "" {
+		return services.ResolveScope(r.Context(), s.db, s.sm, userID)
+	}
+
+	id, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return vault.Scope{}, "", "", vault.ErrNotMember
+	}
+	if id == 0 {
+		userKey, err := s.sm.GetSession(r.Context(), userID)
+		if err != nil {
+			return vault.Scope{}, "", "", err
+		}
+		return vault.Personal(userID), userKey, models.RoleOwner, nil
+	}
+	return services.SharedScope(r.Context(), s.db, s.sm, userID, uint(id))
+}
+
+// ActivityResponse is a logged vault access event.
+type ActivityResponse struct {
+	Event     string    `json:"event"`
+	Source    string    `json:"source"`
+	Service   string    `json:"service,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handleActivity returns the user's latest access events, newest first.
+// ?limit= sets the count (default 20, max 100).
+func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	events, err := activity.Recent(s.db, userID, limit)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ActivityResponse, 0, len(events))
+	for _, e := range events {
+		resp = append(resp, ActivityResponse{Event: e.Event, Source: e.Source, Service: e.Service, CreatedAt: e.CreatedAt})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"events":  resp,
+	})
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate limit defaults, overridable with API_* environment variables.
+const (
+	DefaultUserRate    = 60  // Requests per minute per Telegram user
+	DefaultUserBurst   = 20  // Requests a user may make back to back
+	DefaultIPRate      = 120 // Requests per minute per client IP
+	DefaultIPBurst     = 40  // Requests an IP may make back to back
+	DefaultMaxDecrypts = 4   // Decrypt-heavy requests served at once per process
+)
+
+// bucketIdle is how long an untouched bucket is kept. A bucket idle this
+// long has refilled anyway, so dropping it changes nothing.
+const bucketIdle = 10 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is an in-memory token bucket per key (user ID or IP).
+type rateLimiter struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	rate    float64 // Tokens per second
+	burst   float64
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	return &rateLimiter{
+		now:     time.Now,
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow takes a token for key, or reports how long until one is available.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) > bucketIdle {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > bucketIdle {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// limits holds the API's rate limiters and the decrypt concurrency cap.
+type limits struct {
+	users      *rateLimiter
+	ips        *rateLimiter
+	decrypts   chan struct{}
+	trustProxy bool // Take the client IP from X-Forwarded-For
+}
+
+// newLimitsFromEnv builds the limiters from API_USER_RATE, API_USER_BURST,
+// API_IP_RATE, API_IP_BURST, API_MAX_DECRYPTS and API_TRUST_PROXY.
+func newLimitsFromEnv() *limits {
+	userRate := envInt("API_USER_RATE", DefaultUserRate)
+	userBurst := envInt("API_USER_BURST", DefaultUserBurst)
+	ipRate := envInt("API_IP_RATE", DefaultIPRate)
+	ipBurst := envInt("API_IP_BURST", DefaultIPBurst)
+	trust, _ := strconv.ParseBool(os.Getenv("API_TRUST_PROXY"))
+
+	return &limits{
+		users:      newRateLimiter(userRate, userBurst),
+		ips:        newRateLimiter(ipRate, ipBurst),
+		decrypts:   make(chan struct{}, envInt("API_MAX_DECRYPTS", DefaultMaxDecrypts)),
+		trustProxy: trust,
+	}
+}
+
+// envInt reads a positive integer from the environment, falling back to def.
+func envInt(name string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// clientIP returns the caller's address. Behind a trusted reverse proxy it
+// is the last X-Forwarded-For hop, the one the proxy itself appended.
+func (l *limits) clientIP(r *http.Request) string {
+	if l.trustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			hops := strings.Split(fwd, ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// tooManyRequests writes a 429 with a Retry-After in whole seconds.
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	secs := max(1, int(math.Ceil(retryAfter.Seconds())))
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	http.Error(w, "Too many requests", http.StatusTooManyRequests)
+}
+
+// ipLimitMiddleware limits requests per client IP. It runs before
+// authentication so unauthenticated floods are throttled too.
+func (s *Server) ipLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := s.limits.ips.allow(s.limits.clientIP(r)); !ok {
+			tooManyRequests(w, wait)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// userLimitMiddleware limits requests per authenticated user.
+func (s *Server) userLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := fmt.Sprintf("%d", userIDFromContext(r.Context()))
+		if ok, wait := s.limits.users.allow(key); !ok {
+			tooManyRequests(w, wait)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// decryptLimitMiddleware caps how many decrypt-heavy requests run at once:
+// a per-process cap on the CPU and memory spent on concurrent Argon2 and
+// decrypt work, since each request may decrypt a whole vault. The rate
+// limiters bound requests over time; this bounds them at any one instant.
+// Excess requests are turned away rather than queued.
+func (s *Server) decryptLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case s.limits.decrypts <- struct{}{}:
+			defer func() { <-s.limits.decrypts }()
+			next(w, r)
+		default:
+			tooManyRequests(w, time.Second)
+		}
+	}
+}
+
+// authed wraps a handler that needs a Telegram session.
+func (s *Server) authed(next http.HandlerFunc) http.HandlerFunc {
+	return s.corsMiddleware(s.ipLimitMiddleware(s.authMiddleware(s.userLimitMiddleware(next))))
+}
+
+// decrypting wraps an authenticated handler that decrypts vault entries.
+func (s *Server) decrypting(next http.HandlerFunc) http.HandlerFunc {
+	return s.authed(s.decryptLimitMiddleware(next))
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestRateLimiter returns a limiter with a clock the test controls.
+func newTestRateLimiter(perMinute, burst int) (*rateLimiter, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	l := newRateLimiter(perMinute, burst)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	l, _ := newTestRateLimiter(60, 3)
+
+	for i := 1; i <= 3; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatalf("request %d refused within burst", i)
+		}
+	}
+	ok, wait := l.allow("a")
+	if ok {
+		t.Fatal("request past burst allowed")
+	}
+	if wait != time.Second {
+		t.Fatalf("wait = %s, want 1s", wait)
+	}
+
+	// Keys do not share buckets
+	if ok, _ := l.allow("b"); !ok {
+		t.Fatal("other key refused")
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	l, now := newTestRateLimiter(60, 2)
+	l.allow("a")
+	l.allow("a")
+
+	*now = now.Add(500 * time.Millisecond)
+	if ok, wait := l.allow("a"); ok || wait != 500*time.Millisecond {
+		t.Fatalf("after 0.5s: ok = %v, wait = %s, want refused for 500ms", ok, wait)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.allow("a"); !ok {
+		t.Fatal("refused after a token refilled")
+	}
+
+	// Refill stops at the burst size
+	*now = now.Add(time.Hour)
+	for i := 1; i <= 2; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatalf("request %d refused after idle", i)
+		}
+	}
+	if ok, _ := l.allow("a"); ok {
+		t.Fatal("idle time allowed more than the burst")
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	l, now := newTestRateLimiter(60, 1)
+	l.allow("a")
+
+	*now = now.Add(bucketIdle + time.Second)
+	l.allow("b")
+
+	if _, ok := l.buckets["a"]; ok {
+		t.Fatal("idle bucket kept")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Fatal("active bucket dropped")
+	}
+}
+
+func TestDecryptLimit(t *testing.T) {
+	s := &Server{limits: &limits{decrypts: make(chan struct{}, 1)}}
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := s.decryptLimitMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+
+	done := make(chan struct{})
+	go func() {
+		handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/passwords", nil))
+		close(done)
+	}()
+	<-entered
+
+	// The only slot is taken: the next request is turned away at once
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/api/passwords", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("Retry-After = %q, want 1", rec.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	<-done
+
+	// The slot is given back when the request finishes
+	go func() { <-entered }()
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/api/passwords", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status after release = %d, want 200", rec.Code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{name: "remote address", remoteAddr: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "forwarded ignored when untrusted", remoteAddr: "203.0.113.7:5000", forwarded: "198.51.100.1", want: "203.0.113.7"},
+		{name: "trusted proxy", trustProxy: true, remoteAddr: "10.0.0.1:5000", forwarded: "198.51.100.1", want: "198.51.100.1"},
+		{name: "spoofed hops skipped", trustProxy: true, remoteAddr: "10.0.0.1:5000", forwarded: "1.2.3.4, 198.51.100.1", want: "198.51.100.1"},
+		{name: "trusted without header", trustProxy: true, remoteAddr: "10.0.0.1:5000", want: "10.0.0.1"},
+		{name: "empty last hop", trustProxy: true, remoteAddr: "10.0.0.1:5000", forwarded: "198.51.100.1, ", want: "10.0.0.1"},
+		{name: "address without port", remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/passwords", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			l := &limits{trustProxy: tt.trustProxy}
+			if got := l.clientIP(r); got != tt.want {
+				t.Fatalf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,20 +1,75 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"time"
 
+	"passportier-bot/internal/breach"
+	"passportier-bot/internal/models"
 	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
 
 	"gorm.io/gorm"
 )
 
 // PasswordResponse represents a password entry for API response.
+// Data keeps the legacy text rendering for older Mini App pages.
 type PasswordResponse struct {
-	ID      uint   `json:"id"`
+	ID         uint              `json:"id"`
+	Service    string            `json:"service"`
+	Data       string            `json:"data"`
+	Credential models.Credential `json:"credential"`
+	Favorite   bool              `json:"favorite"`
+	ExpiresAt  *time.Time        `json:"expires_at,omitempty"`
+	Rotation   int               `json:"rotation_days,omitempty"`
+}
+
+// SearchResult is a ranked service name for the search API.
+type SearchResult struct {
 	Service string `json:"service"`
-	Data    string `json:"data"`
+	Kind    string `json:"kind"`
+	Score   int    `json:"score"`
+}
+
+// VaultResponse is a team vault with the caller's role.
+type VaultResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// BreachResponse is a breached entry in the audit API.
+type BreachResponse struct {
+	Service string `json:"service"`
+	Count   int    `json:"count"`
+}
+
+// HealthResponse is the vault health report for the API.
+type HealthResponse struct {
+	Total         int             `json:"total"`
+	Weak          []WeakResponse  `json:"weak"`
+	Reused        [][]string      `json:"reused"`
+	Stale         []StaleResponse `json:"stale"`
+	Undecryptable []string        `json:"undecryptable"`
+	StaleMonths   int             `json:"stale_months"`
+}
+
+// WeakResponse is a weak entry in the health report.
+type WeakResponse struct {
+	Service  string `json:"service"`
+	Score    int    `json:"score"`
+	Feedback string `json:"feedback"`
+}
+
+// StaleResponse is a stale entry in the health report.
+type StaleResponse struct {
+	Service           string    `json:"service"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
 }
 
 // Server handles HTTP API requests.
@@ -22,6 +77,11 @@ type Server struct {
 	db       *gorm.DB
 	sm       *security.SessionManager
 	botToken string
+	breaches breach.RangeStore
+	limits   *limits
+	origins  map[string]bool // CORS allowlist
+	http     *http.Server
+	mounts   map[string]http.Handler // Extra routes, e.g. the bot webhook
 }
 
 // NewServer creates a new API server.
@@ -30,27 +90,117 @@ func NewServer(db *gorm.DB, sm *security.SessionManager) *Server {
 		db:       db,
 		sm:       sm,
 		botToken: os.Getenv("BOT_TOKEN"),
+		breaches: breach.NewStoreFromEnv(),
+		limits:   newLimitsFromEnv(),
+		origins:  allowedOrigins(os.Getenv("WEBAPP_URL"), services.ShareBaseURL()),
+		mounts:   make(map[string]http.Handler),
 	}
 }
 
-// Start starts the HTTP server.
+// Mount serves h on pattern without CORS, auth or rate limits.
+// It must be called before Start.
+func (s *Server) Mount(pattern string, h http.Handler) {
+	s.mounts[pattern] = h
+}
+
+// HTTP server timeouts. Decrypt-heavy responses stay well under WriteTimeout.
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 10 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 60 * time.Second
+)
+
+// allowedOrigins returns the origins (scheme://host[:port]) of the Mini App
+// pages, the only ones allowed to call the API from a browser.
+func allowedOrigins(pages ...string) map[string]bool {
+	origins := make(map[string]bool)
+	for _, page := range pages {
+		u, err := url.Parse(page)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			continue
+		}
+		origins[u.Scheme+"://"+u.Host] = true
+	}
+	log.Printf("[API] CORS allowed origins: %v", origins)
+	return origins
+}
+
+// Start starts the HTTP server and blocks until it fails or is shut down.
 func (s *Server) Start(addr string) error {
-	http.HandleFunc("/api/passwords", s.corsMiddleware(s.handlePasswords))
-	http.HandleFunc("/api/password", s.corsMiddleware(s.handleGetOne))
-	http.HandleFunc("/api/delete", s.corsMiddleware(s.handleDelete))
-	http.HandleFunc("/api/update", s.corsMiddleware(s.handleUpdate))
-	
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/passwords", s.decrypting(s.handlePasswords))
+	mux.HandleFunc("/api/password", s.decrypting(s.handleGetOne))
+	mux.HandleFunc("/api/search", s.decrypting(s.handleSearch))
+	mux.HandleFunc("/api/delete", s.authed(s.handleDelete))
+	mux.HandleFunc("/api/update", s.decrypting(s.handleUpdate))
+	mux.HandleFunc("/api/favorite", s.authed(s.handleFavorite))
+	mux.HandleFunc("/api/vaults", s.authed(s.handleVaults))
+	mux.HandleFunc("/api/activity", s.authed(s.handleActivity))
+	mux.HandleFunc("/api/totp", s.decrypting(s.handleTOTP))
+	mux.HandleFunc("/api/generate", s.authed(s.handleGenerate))
+	mux.HandleFunc("/api/audit", s.decrypting(s.handleAudit))
+	mux.HandleFunc("/api/health", s.decrypting(s.handleHealth))
+	// Share links are opened by recipients without a Telegram session
+	mux.HandleFunc("/api/share/", s.corsMiddleware(s.ipLimitMiddleware(s.decryptLimitMiddleware(s.handleShare))))
+	mux.HandleFunc("/api/history", s.decrypting(s.handleHistory))
+	mux.HandleFunc("/api/history/restore", s.decrypting(s.handleRestore))
+	for pattern, h := range s.mounts {
+		mux.Handle(pattern, h)
+	}
+
+	s.http = &http.Server{
+		Addr:              addr,
+		Handler:           securityHeaders(mux),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		MaxHeaderBytes:    1 << 16,
+	}
+
 	log.Printf("[API] Starting server on %s", addr)
-	return http.ListenAndServe(addr, nil)
+	if err := s.http.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting requests and waits for in-flight ones until ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.http == nil {
+		return nil
+	}
+	return s.http.Shutdown(ctx)
 }
 
-// corsMiddleware adds CORS headers.
+// securityHeaders marks every response as non-cacheable JSON that must not be
+// framed, sniffed or loaded over plain HTTP.
+func securityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+		h.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		h.Set("Cache-Control", "no-store")
+		h.Set("Pragma", "no-cache")
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsMiddleware adds CORS headers for allowlisted origins only.
 func (s *Server) corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Telegram-Init-Data")
-		
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); s.origins[origin] {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Telegram-Init-Data")
+			w.Header().Set("Access-Control-Max-Age", "600")
+		}
+
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"passportier-bot/internal/breach"
 	"passportier-bot/internal/handlers"
 	"passportier-bot/internal/security"
 	"passportier-bot/internal/user"
@@ -15,10 +16,16 @@ import (
 )
 
 // New creates and configures a new Telegram bot instance.
-func New(db *gorm.DB, sm *security.SessionManager) (*telebot.Bot, error) {
+// It receives updates through webhook when given, otherwise by long polling.
+func New(db *gorm.DB, sm *security.SessionManager, limiter *security.AttemptLimiter, webhook *Webhook) (*telebot.Bot, error) {
+	var poller telebot.Poller = &telebot.LongPoller{Timeout: 10 * time.Second}
+	if webhook != nil {
+		poller = webhook
+	}
+
 	pref := telebot.Settings{
 		Token:  os.Getenv("BOT_TOKEN"),
-		Poller: &telebot.LongPoller{Timeout: 10 * time.Second},
+		Poller: poller,
 		OnError: func(err error, c telebot.Context) {
 			if c != nil {
 				log.Printf("[ERROR] Update %v failed: %v", c.Update().ID, err)
@@ -34,35 +41,60 @@ func New(db *gorm.DB, sm *security.SessionManager) (*telebot.Bot, error) {
 	}
 
 	b.Use(middleware.Logger())
-	RegisterHandlers(b, db, sm)
+	RegisterHandlers(b, db, sm, limiter)
 	SetCommands(b)
 
 	return b, nil
 }
 
 // RegisterHandlers registers all bot command and message handlers.
-func RegisterHandlers(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) {
+func RegisterHandlers(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager, limiter *security.AttemptLimiter) {
 	b.Handle("/start", HandleOnboarding())
 	b.Handle("/add", handlers.HandleAdd())
 	b.Handle("/passwords", handlers.HandleListWebApp())
 	b.Handle("/settings", user.HandleSettings())
-	b.Handle("/unlock", handlers.HandleUnlock(b, sm, db))
-	b.Handle("/lock", handlers.HandleLock(b, sm))
+	b.Handle("/unlock", handlers.HandleUnlock(b, sm, db, limiter))
+	b.Handle("/lock", handlers.HandleLock(b, sm, db))
+	b.Handle("/changepass", handlers.HandleChangePass(b, sm, db, limiter))
+	b.Handle("/recover", handlers.HandleRecover(b, sm, db, limiter))
 	b.Handle("/get", handlers.HandleGet(b, db, sm))
+	b.Handle("/history", handlers.HandleHistory(b, db, sm))
 	b.Handle("/list", handlers.HandleList(b, db, sm))
+	b.Handle("/fav", handlers.HandleFavorite(b, db, sm))
+	b.Handle("/folder", handlers.HandleFolder(b, db, sm))
+	b.Handle("/tags", handlers.HandleTags(b, db, sm))
+	b.Handle("/expire", handlers.HandleExpire(b, db))
+	b.Handle("/share", handlers.HandleShare(b, db, sm))
+	b.Handle("/vault", handlers.HandleVault(b, db, sm))
+	b.Handle("/emergency", handlers.HandleEmergency(b, db, sm))
+	b.Handle("/generate", handlers.HandleGenerate(b, db, sm))
+	b.Handle("/audit", handlers.HandleAudit(b, db, sm, breach.NewStoreFromEnv()))
+	b.Handle("/health", handlers.HandleHealth(b, db, sm))
+	b.Handle("/activity", handlers.HandleActivity(db))
+	b.Handle("/export", handlers.HandleExport(b, db, sm))
+	b.Handle("/import", handlers.HandleImport(b))
+	b.Handle(telebot.OnDocument, handlers.HandleDocument(b, db, sm))
 	b.Handle(telebot.OnText, handlers.HandleText(b, db, sm))
 	
 	// Settings callback
 	b.Handle(telebot.OnCallback, user.HandleAutoLockCallback(db))
+	b.Handle(&telebot.InlineButton{Unique: "history_keep"}, user.HandleHistoryRetentionCallback(db))
+	b.Handle(&telebot.InlineButton{Unique: "meta_encrypt"}, user.HandleMetadataEncryptionCallback(db, sm))
     
 	// WebApp Data Handler
 	b.Handle(telebot.OnWebApp, HandleWebApp(b, db, sm))
     
 	// Inline Query logic
 	b.Handle(telebot.OnQuery, HandleInlineQuery(b, db, sm))
+	b.Handle(telebot.OnInlineResult, HandleInlineResult(db))
 
 	// Register inline button callbacks
 	handlers.RegisterListCallbacks(b, db, sm)
+	handlers.RegisterSearchCallbacks(b, db, sm)
+	handlers.RegisterImportCallbacks(b, db, sm)
+	handlers.RegisterHistoryCallbacks(b, db, sm)
+	handlers.RegisterVaultCallbacks(b, db)
+	handlers.RegisterEmergencyCallbacks(b, db)
 }
 
 // SetCommands registers bot commands with Telegram for the menu.
@@ -73,8 +105,24 @@ func SetCommands(b *telebot.Bot) {
 		{Text: "passwords", Description: "📋 Parol menejeri (Web App)"},
 		{Text: "unlock", Description: "🔓 Sessiyani ochish"},
 		{Text: "lock", Description: "🔒 Sessiyani yopish"},
+		{Text: "changepass", Description: "🔑 Maxfiy so'zni o'zgartirish"},
+		{Text: "recover", Description: "🧯 Tiklash kodlari (/recover new)"},
 		{Text: "list", Description: "📝 Parollar ro'yxati (oddiy)"},
+		{Text: "fav", Description: "⭐ Sevimlilarga qo'shish/olib tashlash"},
+		{Text: "folder", Description: "📁 Papkaga joylash (/folder google Ish)"},
+		{Text: "tags", Description: "🏷 Teglar (/tags google ish,pochta)"},
+		{Text: "share", Description: "🔗 Bir martalik havola (/share wifi)"},
+		{Text: "vault", Description: "👥 Umumiy seyflar (jamoa)"},
+		{Text: "emergency", Description: "🆘 Favqulodda kirish (ishonchli kontakt)"},
+		{Text: "expire", Description: "⌛ Almashtirish muddati (/expire google 90)"},
 		{Text: "get", Description: "🔍 Parol olish (/get instagram)"},
+		{Text: "history", Description: "🕘 Parol tarixi (/history instagram)"},
+		{Text: "generate", Description: "🎲 Xavfsiz parol yaratish"},
+		{Text: "health", Description: "🩺 Seyf holati: zaif, takroriy, eskirgan parollar"},
+		{Text: "audit", Description: "🛡 Sizib chiqqan parollarni tekshirish (/audit breach)"},
+		{Text: "activity", Description: "🕵️ So'nggi faollik (kim, qachon, nima)"},
+		{Text: "export", Description: "📦 Seyfni eksport qilish"},
+		{Text: "import", Description: "📥 Parollarni import qilish"},
 		{Text: "settings", Description: "⚙️ Sozlamalar"},
 	}
 
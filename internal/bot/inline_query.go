@@ -3,23 +3,32 @@ package bot
 import (
 	"context"
 	"fmt"
+	"log"
+	"sort"
+	"strconv"
 	"strings"
 
+	"passportier-bot/internal/activity"
 	"passportier-bot/internal/models"
 	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+	"passportier-bot/internal/totp"
 	"passportier-bot/internal/vault"
 
 	"gopkg.in/telebot.v3"
 	"gorm.io/gorm"
 )
 
+// maxInlineResults is Telegram's limit on results per inline answer.
+const maxInlineResults = 50
+
 // HandleInlineQuery handles inline search reuqests (@BotName query).
 func HandleInlineQuery(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
 	return func(c telebot.Context) error {
-		query := strings.ToLower(c.Query().Text)
+		query := strings.TrimSpace(c.Query().Text)
 		userID := c.Sender().ID
 
-		userKey, err := sm.GetSession(context.Background(), userID)
+		scope, userKey, _, err := services.ResolveScope(context.Background(), db, sm, userID)
 		if err != nil {
 			article := &telebot.ArticleResult{
 				ResultBase: telebot.ResultBase{
@@ -39,44 +48,114 @@ func HandleInlineQuery(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager)
 			})
 		}
 
-		var entries []models.PasswordEntry
-		dbSearch := db.Where("user_id = ?", userID)
-		if query != "" {
-			dbSearch = dbSearch.Where("LOWER(service) LIKE ?", "%"+query+"%")
-		} else {
-			dbSearch = dbSearch.Limit(50)
-		}
-		
-		if err := dbSearch.Find(&entries).Error; err != nil {
+		entries, err := inlineEntries(db, scope, query)
+		if err != nil {
 			return c.Answer(&telebot.QueryResponse{Results: []telebot.Result{}})
 		}
 
-		results := buildInlineResults(db, userID, userKey, entries)
-		return c.Answer(&telebot.QueryResponse{Results: results, CacheTime: 5, IsPersonal: true})
+		results, revealed := buildInlineResults(userKey, entries)
+		if err := c.Answer(&telebot.QueryResponse{Results: results, CacheTime: 1, IsPersonal: true}); err != nil {
+			return err
+		}
+
+		// The answer already carries the plaintext to the user's client, whether
+		// or not a result is then sent (which HandleInlineResult logs)
+		if len(revealed) > 0 {
+			activity.Record(db, userID, activity.EventInlineQuery, activity.SourceInline, strings.Join(revealed, ", "))
+		}
+		return nil
+	}
+}
+
+// inlineEntries returns the entries to offer for an inline query, in ranked
+// order. An empty query lists favorites first.
+func inlineEntries(db *gorm.DB, scope vault.Scope, query string) ([]models.PasswordEntry, error) {
+	if query == "" {
+		entries, err := vault.ListEntries(db, scope)
+		if len(entries) > maxInlineResults {
+			entries = entries[:maxInlineResults]
+		}
+		return entries, err
+	}
+
+	matches, err := vault.SearchEntries(db, scope, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > maxInlineResults {
+		matches = matches[:maxInlineResults]
+	}
+
+	names := make([]string, len(matches))
+	rank := make(map[string]int, len(matches))
+	for i, m := range matches {
+		names[i] = m.Name
+		rank[m.Name] = i
+	}
+
+	entries, err := vault.GetEntries(db, scope, names)
+	if err != nil {
+		return nil, err
 	}
+	sort.Slice(entries, func(i, j int) bool { return rank[entries[i].Service] < rank[entries[j].Service] })
+	return entries, nil
 }
 
-func buildInlineResults(db *gorm.DB, userID int64, userKey string, entries []models.PasswordEntry) []telebot.Result {
+// buildInlineResults decrypts entries into inline results. It also returns the
+// services that were decrypted; entries that fail to decrypt are skipped.
+func buildInlineResults(userKey string, entries []models.PasswordEntry) ([]telebot.Result, []string) {
 	results := make([]telebot.Result, 0, len(entries))
-	for _, entry := range entries {
-		decrypted, err := vault.RetrieveCredential(db, userID, entry.Service, userKey)
+	var revealed []string
+	for i := range entries {
+		entry := &entries[i]
+		cred, err := vault.DecryptCredential(entry, userKey)
 		if err != nil {
 			continue
 		}
 
-		text := fmt.Sprintf("🔑 *%s*\n||%s||", entry.Service, decrypted)
+		text := fmt.Sprintf("🔑 *%s*\n", entry.Service)
+		if cred.Login != "" {
+			text += fmt.Sprintf("👤 `%s`\n", cred.Login)
+		}
+		text += fmt.Sprintf("||%s||", cred.Password)
+		description := "Tap to send password"
+		if cred.TOTP != "" {
+			if code, err := totp.Now(cred.TOTP); err == nil {
+				text += fmt.Sprintf("\n🔢 `%s`", code.Code)
+				description = fmt.Sprintf("2FA: %s (%d s)", code.Code, code.Remaining)
+			}
+		}
 		article := &telebot.ArticleResult{
 			ResultBase: telebot.ResultBase{
 				ID: fmt.Sprintf("%d", entry.ID),
 			},
 			Title:       entry.Service,
-			Description: "Tap to send password",
+			Description: description,
 		}
 		article.SetContent(&telebot.InputTextMessageContent{
 			Text:      text,
 			ParseMode: telebot.ModeMarkdown,
 		})
 		results = append(results, article)
+		revealed = append(revealed, entry.Service)
+	}
+	return results, revealed
+}
+
+// HandleInlineResult logs an inline result the user sent into a chat.
+// Telegram reports chosen results only when inline feedback is enabled for the bot.
+func HandleInlineResult(db *gorm.DB) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		id, err := strconv.ParseUint(c.InlineResult().ResultID, 10, 64)
+		if err != nil {
+			return nil // Not an entry (e.g. the unlock hint)
+		}
+
+		var entry models.PasswordEntry
+		if err := db.Select("service").Where("id = ?", id).Limit(1).Find(&entry).Error; err != nil {
+			log.Printf("Warning: Failed to look up inline result %d: %v", id, err)
+		}
+		activity.Record(db, c.Sender().ID, activity.EventInlineReveal, activity.SourceInline, entry.Service)
+		return nil
 	}
-	return results
 }
@@ -0,0 +1,15 @@
+package bot
+
+import (
+	"passportier-bot/internal/reminder"
+
+	"gopkg.in/telebot.v3"
+)
+
+// Notifier sends reminder messages through the bot as plain text.
+func Notifier(b *telebot.Bot) reminder.Notifier {
+	return func(userID int64, text string) error {
+		_, err := b.Send(&telebot.User{ID: userID}, text)
+		return err
+	}
+}
@@ -3,20 +3,50 @@ package bot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 
+	"passportier-bot/internal/activity"
+	"passportier-bot/internal/models"
 	"passportier-bot/internal/security"
 	"passportier-bot/internal/services"
+	"passportier-bot/internal/totp"
+	"passportier-bot/internal/vault"
 
 	"gopkg.in/telebot.v3"
 	"gorm.io/gorm"
 )
 
 // WebAppPayload represents the JSON structure sent by the Mini App.
+// Data is the legacy free-text form; structured fields take precedence.
 type WebAppPayload struct {
-	Service string `json:"service"`
-	Data    string `json:"data"`
+	Service  string   `json:"service"`
+	Login    string   `json:"login"`
+	Password string   `json:"password"`
+	URLs     []string `json:"urls"`
+	Notes    string   `json:"notes"`
+	TOTP     string   `json:"totp"`
+	Folder   string   `json:"folder"`
+	Tags     []string `json:"tags"`
+	Data     string   `json:"data"`
+}
+
+// Credential converts the payload into a structured credential.
+func (p WebAppPayload) Credential() models.Credential {
+	cred := models.Credential{
+		Login:    p.Login,
+		Password: p.Password,
+		URLs:     p.URLs,
+		Notes:    p.Notes,
+		TOTP:     p.TOTP,
+	}
+	if cred.IsEmpty() {
+		cred = vault.ParseCredential(p.Data)
+	}
+	cred.Folder = p.Folder
+	cred.Tags = p.Tags
+	return cred
 }
 
 // HandleWebApp processes data sent from the Mini App.
@@ -36,7 +66,8 @@ func HandleWebApp(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) tele
 			return c.Send("❌ Ma'lumot formati noto'g'ri.")
 		}
 		
-		if payload.Service == "" || payload.Data == "" {
+		cred := payload.Credential()
+		if payload.Service == "" || cred.IsEmpty() {
 			return c.Send("⚠️ Xizmat nomi va ma'lumot bo'sh bo'lmasligi kerak.")
 		}
 
@@ -46,11 +77,18 @@ func HandleWebApp(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) tele
 			return c.Send("🔒 Sessiya yopiq! Iltimos, avval `/unlock` qiling va qayta urinib ko'ring.")
 		}
 
-		if err := services.SavePassword(context.Background(), db, sm, c.Sender().ID, payload.Service, payload.Data); err != nil {
+		if err := services.SavePassword(context.Background(), db, sm, c.Sender().ID, payload.Service, cred); err != nil {
+			if errors.Is(err, totp.ErrInvalidSecret) {
+				return c.Send("⚠️ 2FA kaliti noto'g'ri. Base32 kalit yoki `otpauth://` havolasini yuboring.", telebot.ModeMarkdown)
+			}
+			if errors.Is(err, vault.ErrForbidden) {
+				return c.Send("👁 Bu umumiy seyfda faqat ko'rish huquqingiz bor.")
+			}
 			log.Printf("Failed to save from WebApp: %v", err)
 			return c.Send("❌ Saqlashda xatolik yuz berdi.")
 		}
 
+		activity.Record(db, c.Sender().ID, activity.EventUpdate, activity.SourceWebApp, payload.Service)
 		return c.Send(fmt.Sprintf("✅ *%s* muvaffaqiyatli saqlandi!", payload.Service), telebot.ModeMarkdown)
 	}
 }
@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/telebot.v3"
+)
+
+// maxUpdateSize bounds a webhook request body.
+const maxUpdateSize = 1 << 20
+
+// secretTokenPattern is the character set Telegram accepts for secret_token.
+var secretTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// Webhook receives updates pushed by Telegram. It is a telebot.Poller that
+// is also an http.Handler, mounted on the API server under a secret path.
+type Webhook struct {
+	publicURL string // Base URL Telegram can reach, e.g. https://bot.example.com
+	path      string
+	secret    string
+
+	mu   sync.RWMutex
+	dest chan<- telebot.Update
+	stop <-chan struct{}
+}
+
+// WebhookFromEnv configures webhook mode from WEBHOOK_URL and WEBHOOK_SECRET.
+// It returns nil when WEBHOOK_URL is unset, meaning long polling. Without
+// WEBHOOK_SECRET the secret token is derived from the bot token, as is the path.
+func WebhookFromEnv() (*Webhook, error) {
+	base := os.Getenv("WEBHOOK_URL")
+	if base == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("WEBHOOK_URL must be an https URL, got %q", base)
+	}
+
+	token := os.Getenv("BOT_TOKEN")
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		secret = deriveSecret(token, "webhook-secret")
+	} else if !secretTokenPattern.MatchString(secret) {
+		return nil, fmt.Errorf("WEBHOOK_SECRET may only contain A-Z, a-z, 0-9, _ and -")
+	}
+
+	return &Webhook{
+		publicURL: strings.TrimSuffix(u.String(), "/"),
+		path:      "/telegram/" + deriveSecret(token, "webhook-path")[:32],
+		secret:    secret,
+	}, nil
+}
+
+// deriveSecret derives a stable hex secret from the bot token.
+func deriveSecret(token, purpose string) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Path returns the secret path to mount the webhook on.
+func (w *Webhook) Path() string {
+	return w.path
+}
+
+// Register points Telegram at the webhook.
+func (w *Webhook) Register(b *telebot.Bot) error {
+	return b.SetWebhook(&telebot.Webhook{
+		Endpoint:    &telebot.WebhookEndpoint{PublicURL: w.publicURL + w.path},
+		SecretToken: w.secret,
+	})
+}
+
+// Poll hands incoming webhook requests to the bot until it is stopped.
+func (w *Webhook) Poll(b *telebot.Bot, dest chan telebot.Update, stop chan struct{}) {
+	w.mu.Lock()
+	w.dest, w.stop = dest, stop
+	w.mu.Unlock()
+
+	<-stop
+
+	w.mu.Lock()
+	w.dest, w.stop = nil, nil
+	w.mu.Unlock()
+}
+
+// ServeHTTP verifies the secret token and queues the update. While the bot
+// is not polling it answers 503, so Telegram retries the delivery later.
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	got := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if subtle.ConstantTimeCompare([]byte(got), []byte(w.secret)) != 1 {
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var update telebot.Update
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxUpdateSize)).Decode(&update); err != nil {
+		http.Error(rw, "Invalid update", http.StatusBadRequest)
+		return
+	}
+
+	w.mu.RLock()
+	dest, stop := w.dest, w.stop
+	w.mu.RUnlock()
+	if dest == nil {
+		http.Error(rw, "Bot not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case dest <- update:
+		rw.WriteHeader(http.StatusOK)
+	case <-stop:
+		http.Error(rw, "Bot stopping", http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+	}
+}
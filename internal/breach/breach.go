@@ -0,0 +1,129 @@
+// Package breach checks passwords against a Have I Been Pwned style
+// k-anonymity range store.
+//
+// A password is hashed with SHA-1 and only the first 5 hex characters of the
+// hash (the prefix) are ever given to a store. The store answers with every
+// known hash suffix under that prefix and the match happens locally, so the
+// password and its full hash never leave the process.
+package breach
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PrefixLen is the number of hex characters of the SHA-1 hash sent to a store.
+const PrefixLen = 5
+
+// ErrInvalidPrefix is returned by stores for anything but 5 uppercase hex characters.
+var ErrInvalidPrefix = errors.New("invalid hash prefix")
+
+// RangeStore looks up all known hash suffixes for a prefix.
+// Implementations must never receive more than PrefixLen characters.
+type RangeStore interface {
+	// Range returns suffix (35 uppercase hex chars) -> times seen.
+	Range(ctx context.Context, prefix string) (map[string]int, error)
+}
+
+// Checker reports how often a password appears in the breach corpus.
+// Range answers are cached per Checker, so reuse one per audit run.
+type Checker struct {
+	store RangeStore
+
+	mu    sync.Mutex
+	cache map[string]map[string]int
+}
+
+// NewChecker creates a checker backed by store.
+func NewChecker(store RangeStore) *Checker {
+	return &Checker{store: store, cache: make(map[string]map[string]int)}
+}
+
+// Check returns how many times password was seen in breaches (0 if never).
+func (c *Checker) Check(ctx context.Context, password string) (int, error) {
+	prefix, suffix := Hash(password)
+
+	c.mu.Lock()
+	suffixes, ok := c.cache[prefix]
+	c.mu.Unlock()
+
+	if !ok {
+		var err error
+		suffixes, err = c.store.Range(ctx, prefix)
+		if err != nil {
+			return 0, err
+		}
+
+		c.mu.Lock()
+		c.cache[prefix] = suffixes
+		c.mu.Unlock()
+	}
+
+	return suffixes[suffix], nil
+}
+
+// Hash returns the uppercase hex SHA-1 of password split into the
+// PrefixLen-character prefix and the remaining suffix.
+func Hash(password string) (prefix, suffix string) {
+	sum := sha1.Sum([]byte(password))
+	h := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return h[:PrefixLen], h[PrefixLen:]
+}
+
+// validPrefix reports whether prefix is exactly PrefixLen uppercase hex characters.
+func validPrefix(prefix string) bool {
+	if len(prefix) != PrefixLen {
+		return false
+	}
+	for _, r := range prefix {
+		if !(r >= '0' && r <= '9' || r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRange reads "SUFFIX:COUNT" lines, the format of both the range API
+// and per-prefix files. Zero-count padding lines are skipped.
+func parseRange(r io.Reader) (map[string]int, error) {
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		suffix, countStr, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed range line %q", line)
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(countStr))
+		if err != nil {
+			return nil, fmt.Errorf("malformed range count %q", countStr)
+		}
+		if count > 0 {
+			suffixes[strings.ToUpper(strings.TrimSpace(suffix))] = count
+		}
+	}
+	return suffixes, scanner.Err()
+}
+
+// NewStoreFromEnv picks the store from the environment:
+// HIBP_RANGE_DIR selects a local FileStore, otherwise an HTTPStore is used
+// with HIBP_API_URL (default: the public Pwned Passwords API).
+func NewStoreFromEnv() RangeStore {
+	if dir := os.Getenv("HIBP_RANGE_DIR"); dir != "" {
+		return NewFileStore(dir)
+	}
+	return NewHTTPStore(os.Getenv("HIBP_API_URL"))
+}
@@ -0,0 +1,39 @@
+package breach
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileStore serves ranges from a directory of per-prefix files
+// (<PREFIX>.txt with "SUFFIX:COUNT" lines), as written by the Pwned Passwords
+// downloader. It is meant for offline deployments and testing.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store reading from dir.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Range reads the file for prefix. A missing file means no known suffixes.
+func (s *FileStore) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	if !validPrefix(prefix) {
+		return nil, ErrInvalidPrefix
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]int{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseRange(f)
+}
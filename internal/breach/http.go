@@ -0,0 +1,55 @@
+package breach
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the public Pwned Passwords range API.
+const DefaultAPIURL = "https://api.pwnedpasswords.com"
+
+// HTTPStore queries a k-anonymity range API (GET {base}/range/{prefix}).
+// Responses are padded so their size does not reveal the prefix's popularity.
+type HTTPStore struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPStore creates a store for baseURL ("" selects DefaultAPIURL).
+func NewHTTPStore(baseURL string) *HTTPStore {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &HTTPStore{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Range fetches the suffixes for prefix.
+func (s *HTTPStore) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	if !validPrefix(prefix) {
+		return nil, ErrInvalidPrefix
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "PassPortierBot")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("range API returned %s", resp.Status)
+	}
+	return parseRange(resp.Body)
+}
@@ -9,6 +9,15 @@ import (
 )
 
 func Encrypt(plaintext []byte, key []byte) ([]byte, error) {
+	return EncryptWithAD(plaintext, key, nil)
+}
+
+func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
+	return DecryptWithAD(ciphertext, key, nil)
+}
+
+// EncryptWithAD is Encrypt with additional authenticated data bound to the ciphertext.
+func EncryptWithAD(plaintext []byte, key []byte, ad []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
@@ -21,10 +30,11 @@ func Encrypt(plaintext []byte, key []byte) ([]byte, error) {
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return nil, err
 	}
-	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+	return gcm.Seal(nonce, nonce, plaintext, ad), nil
 }
 
-func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
+// DecryptWithAD opens data sealed by EncryptWithAD with the same additional data.
+func DecryptWithAD(ciphertext []byte, key []byte, ad []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
@@ -38,5 +48,5 @@ func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
 		return nil, fmt.Errorf("ciphertext too short")
 	}
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	return gcm.Open(nil, nonce, ciphertext, nil)
-}
\ No newline at end of file
+	return gcm.Open(nil, nonce, ciphertext, ad)
+}
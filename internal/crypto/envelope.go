@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+)
+
+// GenerateSalt returns a fresh random salt for Argon2id key derivation.
+func GenerateSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// GenerateDataKey returns a fresh random 256-bit data-encryption key (DEK).
+func GenerateDataKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// WrapDataKey encrypts the DEK under a key-encryption key (KEK) derived
+// from the passphrase with Argon2id. Output format: Nonce[12] + Ciphertext[32+16].
+func WrapDataKey(dataKey []byte, passphrase string, salt []byte) ([]byte, error) {
+	return Encrypt(dataKey, DeriveKey(passphrase, salt))
+}
+
+// UnwrapDataKey recovers the DEK. A wrong passphrase fails GCM authentication
+// and yields ErrInvalidPassword.
+func UnwrapDataKey(wrapped []byte, passphrase string, salt []byte) ([]byte, error) {
+	dataKey, err := Decrypt(wrapped, DeriveKey(passphrase, salt))
+	if err != nil || len(dataKey) != KeySize {
+		return nil, ErrInvalidPassword
+	}
+	return dataKey, nil
+}
+
+// EncodeDataKey encodes a DEK for storage in the session.
+func EncodeDataKey(dataKey []byte) string {
+	return base64.StdEncoding.EncodeToString(dataKey)
+}
+
+// DecodeDataKey decodes a session DEK produced by EncodeDataKey.
+func DecodeDataKey(userKey string) ([]byte, error) {
+	dataKey, err := base64.StdEncoding.DecodeString(userKey)
+	if err != nil || len(dataKey) != KeySize {
+		return nil, ErrInvalidPassword
+	}
+	return dataKey, nil
+}
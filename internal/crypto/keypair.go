@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+)
+
+// sealInfo binds derived keys to their purpose.
+const sealInfo = "passportier-seal-v1"
+
+// ErrSealedKey is returned when sealed data cannot be opened with the private key.
+var ErrSealedKey = errors.New("sealed data cannot be opened")
+
+// GenerateKeyPair returns a fresh X25519 key pair (raw 32-byte keys).
+func GenerateKeyPair() (publicKey, privateKey []byte, err error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv.PublicKey().Bytes(), priv.Bytes(), nil
+}
+
+// SealToPublicKey encrypts plaintext so only the holder of the matching private
+// key can open it: an ephemeral X25519 exchange, HKDF-SHA256 and AES-256-GCM.
+// Output format: EphemeralPublicKey[32] + Nonce[12] + Ciphertext.
+func SealToPublicKey(plaintext, recipientPublicKey []byte) ([]byte, error) {
+	recipient, err := ecdh.X25519().NewPublicKey(recipientPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := sealKey(ephemeral, recipient, ephemeral.PublicKey().Bytes(), recipientPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := Encrypt(plaintext, key)
+	if err != nil {
+		return nil, err
+	}
+	return append(ephemeral.PublicKey().Bytes(), sealed...), nil
+}
+
+// OpenWithPrivateKey opens data produced by SealToPublicKey.
+func OpenWithPrivateKey(sealed, privateKey []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(priv.PublicKey().Bytes())
+	if len(sealed) < size {
+		return nil, ErrSealedKey
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(sealed[:size])
+	if err != nil {
+		return nil, ErrSealedKey
+	}
+
+	key, err := sealKey(priv, ephemeral, sealed[:size], priv.PublicKey().Bytes())
+	if err != nil {
+		return nil, ErrSealedKey
+	}
+
+	plaintext, err := Decrypt(sealed[size:], key)
+	if err != nil {
+		return nil, ErrSealedKey
+	}
+	return plaintext, nil
+}
+
+// sealKey derives the AES key from the X25519 shared secret, salted with both public keys.
+func sealKey(priv *ecdh.PrivateKey, pub *ecdh.PublicKey, ephemeralPub, recipientPub []byte) ([]byte, error) {
+	shared, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	salt := append(append([]byte{}, ephemeralPub...), recipientPub...)
+	return hkdf.Key(sha256.New, shared, salt, sealInfo, KeySize)
+}
@@ -5,10 +5,9 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
 	"encoding/base64"
 	"errors"
-	"io"
+	"strings"
 
 	"golang.org/x/crypto/argon2"
 )
@@ -22,12 +21,15 @@ const (
 	Argon2Time = 1        // Number of iterations
 	Argon2Mem  = 64 * 1024 // Memory in KiB (64 MB)
 	Argon2Threads = 4     // Parallelism factor
+
+	EnvelopePrefix = "v2:" // Version header for DEK-encrypted entries
 )
 
 // Predefined errors for Zero-Knowledge verification
 var (
 	ErrInvalidPassword = errors.New("invalid password or data corrupted")
 	ErrDataTooShort    = errors.New("encrypted data is malformed or too short")
+	ErrLegacyFormat    = errors.New("entry uses legacy per-entry key derivation")
 )
 
 // CryptoManager handles all encryption/decryption operations.
@@ -39,64 +41,65 @@ func NewCryptoManager() *CryptoManager {
 	return &CryptoManager{}
 }
 
-// Encrypt encrypts plaintext using AES-256-GCM with Argon2id key derivation.
+// Encrypt encrypts plaintext using AES-256-GCM under the user's data key.
 //
 // Security Design:
-// 1. A unique random salt is generated for EVERY encryption operation.
+// 1. userKey is the session-encoded DEK (see EncodeDataKey), derived once at /unlock.
 // 2. A unique random nonce is generated for EVERY encryption operation.
-// 3. The salt is embedded in the output, enabling stateless decryption.
-// 4. Output format: base64(Salt[16] + Nonce[12] + Ciphertext[N+16])
+// 3. Output format: "v2:" + base64(Nonce[12] + Ciphertext[N+16])
 //
-// The authentication tag (16 bytes) is appended to ciphertext by GCM.
+// The version prefix cannot occur in legacy blobs (":" is not a base64 character).
 func (cm *CryptoManager) Encrypt(plainText, userKey string) (string, error) {
-	// Generate cryptographically secure random salt
-	salt := make([]byte, SaltSize)
-	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+	dataKey, err := DecodeDataKey(userKey)
+	if err != nil {
 		return "", err
 	}
 
-	// Derive AES-256 key using Argon2id (memory-hard, resistant to GPU/ASIC)
-	aesKey := argon2.IDKey([]byte(userKey), salt, Argon2Time, Argon2Mem, Argon2Threads, KeySize)
-
-	// Create AES cipher block
-	block, err := aes.NewCipher(aesKey)
+	sealed, err := Encrypt([]byte(plainText), dataKey)
 	if err != nil {
 		return "", err
 	}
 
-	// Create GCM mode (provides both confidentiality and authenticity)
-	gcm, err := cipher.NewGCM(block)
+	return EnvelopePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts data encrypted by Encrypt() method.
+// Legacy blobs return ErrLegacyFormat; they are re-encrypted at unlock.
+func (cm *CryptoManager) Decrypt(encryptedData, userKey string) (string, error) {
+	if IsLegacy(encryptedData) {
+		return "", ErrLegacyFormat
+	}
+
+	dataKey, err := DecodeDataKey(userKey)
 	if err != nil {
 		return "", err
 	}
 
-	// Generate random nonce (NEVER reuse with same key)
-	nonce := make([]byte, NonceSize)
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encryptedData, EnvelopePrefix))
+	if err != nil || len(sealed) < NonceSize+17 {
+		return "", ErrDataTooShort
 	}
 
-	// Encrypt and authenticate the plaintext
-	// GCM appends a 16-byte authentication tag to the ciphertext
-	ciphertext := gcm.Seal(nil, nonce, []byte(plainText), nil)
+	// Wrong DEK or tampered data fails GCM authentication
+	plaintext, err := Decrypt(sealed, dataKey)
+	if err != nil {
+		return "", ErrInvalidPassword
+	}
 
-	// Combine: Salt + Nonce + Ciphertext into single blob
-	// This allows stateless decryption without external salt storage
-	combined := make([]byte, SaltSize+NonceSize+len(ciphertext))
-	copy(combined[0:SaltSize], salt)
-	copy(combined[SaltSize:SaltSize+NonceSize], nonce)
-	copy(combined[SaltSize+NonceSize:], ciphertext)
+	return string(plaintext), nil
+}
 
-	return base64.StdEncoding.EncodeToString(combined), nil
+// IsLegacy reports whether the blob predates envelope encryption.
+func IsLegacy(encryptedData string) bool {
+	return !strings.HasPrefix(encryptedData, EnvelopePrefix)
 }
 
-// Decrypt decrypts data encrypted by Encrypt() method.
+// DecryptLegacy decrypts v1 blobs that carry their own Argon2id salt.
 //
-// Zero-Knowledge Verification:
-// The password is validated ONLY by attempting decryption.
-// If GCM authentication fails, it means the password is wrong.
-// This is the core of Zero-Knowledge: we never store password hashes.
-func (cm *CryptoManager) Decrypt(encryptedData, userKey string) (string, error) {
+// Format: base64(Salt[16] + Nonce[12] + Ciphertext[N+16])
+// Each call performs a full 64 MB Argon2id derivation, so it is only used
+// to migrate legacy entries to the envelope format.
+func (cm *CryptoManager) DecryptLegacy(encryptedData, passphrase string) (string, error) {
 	// Decode from base64
 	combined, err := base64.StdEncoding.DecodeString(encryptedData)
 	if err != nil {
@@ -115,7 +118,7 @@ func (cm *CryptoManager) Decrypt(encryptedData, userKey string) (string, error)
 	ciphertext := combined[SaltSize+NonceSize:]
 
 	// Re-derive the AES key using extracted salt and provided password
-	aesKey := argon2.IDKey([]byte(userKey), salt, Argon2Time, Argon2Mem, Argon2Threads, KeySize)
+	aesKey := argon2.IDKey([]byte(passphrase), salt, Argon2Time, Argon2Mem, Argon2Threads, KeySize)
 
 	// Create AES cipher block
 	block, err := aes.NewCipher(aesKey)
@@ -0,0 +1,163 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newTestDataKey returns a fresh session-encoded data key.
+func newTestDataKey(t *testing.T) string {
+	t.Helper()
+	dataKey, err := GenerateDataKey()
+	if err != nil {
+		t.Fatalf("GenerateDataKey: %v", err)
+	}
+	return EncodeDataKey(dataKey)
+}
+
+// encryptLegacy builds a v1 blob the way entries were written before
+// envelope encryption: base64(Salt + Nonce + Ciphertext).
+func encryptLegacy(t *testing.T, plaintext, passphrase string) string {
+	t.Helper()
+	salt, err := GenerateSalt()
+	if err != nil {
+		t.Fatalf("GenerateSalt: %v", err)
+	}
+	sealed, err := Encrypt([]byte(plaintext), DeriveKey(passphrase, salt))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(append(salt, sealed...))
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	cm := NewCryptoManager()
+	userKey := newTestDataKey(t)
+
+	blob, err := cm.Encrypt("hunter2", userKey)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(blob, EnvelopePrefix) || IsLegacy(blob) {
+		t.Fatalf("blob %q lacks the %q header", blob, EnvelopePrefix)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(blob, EnvelopePrefix))
+	if err != nil {
+		t.Fatalf("payload is not base64: %v", err)
+	}
+	if want := NonceSize + len("hunter2") + 16; len(sealed) != want {
+		t.Fatalf("payload is %d bytes, want nonce + ciphertext + tag = %d", len(sealed), want)
+	}
+
+	got, err := cm.Decrypt(blob, userKey)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if got != "hunter2" {
+		t.Fatalf("Decrypt = %q, want hunter2", got)
+	}
+
+	// Every encryption draws a fresh nonce
+	again, err := cm.Encrypt("hunter2", userKey)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if again == blob {
+		t.Fatal("two encryptions produced the same blob")
+	}
+}
+
+func TestEnvelopeDecryptErrors(t *testing.T) {
+	cm := NewCryptoManager()
+	userKey := newTestDataKey(t)
+
+	blob, err := cm.Encrypt("hunter2", userKey)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	sealed, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(blob, EnvelopePrefix))
+	sealed[len(sealed)-1] ^= 1
+	tampered := EnvelopePrefix + base64.StdEncoding.EncodeToString(sealed)
+
+	tests := []struct {
+		name    string
+		blob    string
+		userKey string
+		want    error
+	}{
+		{name: "wrong key", blob: blob, userKey: newTestDataKey(t), want: ErrInvalidPassword},
+		{name: "tampered", blob: tampered, userKey: userKey, want: ErrInvalidPassword},
+		{name: "malformed key", blob: blob, userKey: "not-a-key", want: ErrInvalidPassword},
+		{name: "short key", blob: blob, userKey: base64.StdEncoding.EncodeToString([]byte("short")), want: ErrInvalidPassword},
+		{name: "too short", blob: EnvelopePrefix + base64.StdEncoding.EncodeToString(make([]byte, NonceSize+16)), userKey: userKey, want: ErrDataTooShort},
+		{name: "not base64", blob: EnvelopePrefix + "!!!", userKey: userKey, want: ErrDataTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := cm.Decrypt(tt.blob, tt.userKey); !errors.Is(err, tt.want) {
+				t.Fatalf("Decrypt err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacyFormat(t *testing.T) {
+	cm := NewCryptoManager()
+	blob := encryptLegacy(t, "hunter2", "correct horse")
+
+	if !IsLegacy(blob) {
+		t.Fatal("legacy blob not recognised")
+	}
+
+	// The envelope path refuses legacy blobs without trying the data key
+	if _, err := cm.Decrypt(blob, newTestDataKey(t)); !errors.Is(err, ErrLegacyFormat) {
+		t.Fatalf("Decrypt err = %v, want ErrLegacyFormat", err)
+	}
+
+	got, err := cm.DecryptLegacy(blob, "correct horse")
+	if err != nil {
+		t.Fatalf("DecryptLegacy: %v", err)
+	}
+	if got != "hunter2" {
+		t.Fatalf("DecryptLegacy = %q, want hunter2", got)
+	}
+
+	if _, err := cm.DecryptLegacy(blob, "wrong horse"); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("DecryptLegacy with the wrong passphrase: err = %v, want ErrInvalidPassword", err)
+	}
+	short := base64.StdEncoding.EncodeToString(make([]byte, SaltSize+NonceSize+16))
+	if _, err := cm.DecryptLegacy(short, "correct horse"); !errors.Is(err, ErrDataTooShort) {
+		t.Fatalf("DecryptLegacy of a short blob: err = %v, want ErrDataTooShort", err)
+	}
+}
+
+func TestWrapDataKey(t *testing.T) {
+	dataKey, err := GenerateDataKey()
+	if err != nil {
+		t.Fatalf("GenerateDataKey: %v", err)
+	}
+	salt, err := GenerateSalt()
+	if err != nil {
+		t.Fatalf("GenerateSalt: %v", err)
+	}
+
+	wrapped, err := WrapDataKey(dataKey, "correct horse", salt)
+	if err != nil {
+		t.Fatalf("WrapDataKey: %v", err)
+	}
+	got, err := UnwrapDataKey(wrapped, "correct horse", salt)
+	if err != nil {
+		t.Fatalf("UnwrapDataKey: %v", err)
+	}
+	if EncodeDataKey(got) != EncodeDataKey(dataKey) {
+		t.Fatal("unwrapped key differs from the original")
+	}
+
+	if _, err := UnwrapDataKey(wrapped, "wrong horse", salt); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("UnwrapDataKey with the wrong passphrase: err = %v, want ErrInvalidPassword", err)
+	}
+}
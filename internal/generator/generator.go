@@ -0,0 +1,214 @@
+// Package generator creates cryptographically secure passwords and
+// diceware-style passphrases.
+package generator
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Character classes used for random passwords.
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!@#$%^&*()-_=+[]{};:,.?/"
+
+	ambiguousChars = "Il1O0o|`'\";:,."
+)
+
+// Limits for user-supplied options.
+const (
+	DefaultLength = 20
+	MinLength     = 8
+	MaxLength     = 128
+	DefaultWords  = 6 // ~77 bits with the EFF list
+	MinWords      = 4 // ~52 bits, on par with a MinLength password
+	MaxWords      = 12
+)
+
+// Predefined option errors
+var (
+	ErrInvalidLength = errors.New("password length out of range")
+	ErrInvalidWords  = errors.New("word count out of range")
+	ErrNoClasses     = errors.New("no character classes selected")
+)
+
+// wordlist.txt is the EFF large wordlist (7776 words, ~12.9 bits each),
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed wordlist.txt
+var wordlistData string
+
+var wordlist = strings.Fields(wordlistData)
+
+// Options controls password generation. Words > 0 selects passphrase mode.
+type Options struct {
+	Length           int
+	Lower            bool
+	Upper            bool
+	Digits           bool
+	Symbols          bool
+	ExcludeAmbiguous bool
+	Words            int
+	Separator        string
+}
+
+// DefaultOptions returns a 20-character password using all character classes.
+func DefaultOptions() Options {
+	return Options{
+		Length:  DefaultLength,
+		Lower:   true,
+		Upper:   true,
+		Digits:  true,
+		Symbols: true,
+	}
+}
+
+// Generate returns a new password or passphrase according to opts.
+func Generate(opts Options) (string, error) {
+	if opts.Words > 0 {
+		return generatePassphrase(opts)
+	}
+	return generatePassword(opts)
+}
+
+// Entropy estimates the strength of a generated secret in bits.
+func Entropy(opts Options) float64 {
+	if opts.Words > 0 {
+		return float64(opts.Words) * math.Log2(float64(len(wordlist)))
+	}
+	pool := strings.Join(classes(opts), "")
+	if pool == "" {
+		return 0
+	}
+	return float64(opts.Length) * math.Log2(float64(len(pool)))
+}
+
+// generatePassword draws uniformly from the selected classes and guarantees
+// at least one character from each of them.
+func generatePassword(opts Options) (string, error) {
+	if opts.Length < MinLength || opts.Length > MaxLength {
+		return "", ErrInvalidLength
+	}
+
+	sets := classes(opts)
+	if len(sets) == 0 {
+		return "", ErrNoClasses
+	}
+	pool := strings.Join(sets, "")
+
+	out := make([]byte, opts.Length)
+	for i := range out {
+		c, err := pick(pool)
+		if err != nil {
+			return "", err
+		}
+		out[i] = c
+	}
+
+	// Place one character of each class at distinct random positions
+	positions, err := permutation(opts.Length)
+	if err != nil {
+		return "", err
+	}
+	for i, set := range sets {
+		c, err := pick(set)
+		if err != nil {
+			return "", err
+		}
+		out[positions[i]] = c
+	}
+
+	return string(out), nil
+}
+
+// generatePassphrase joins random words from the embedded wordlist.
+func generatePassphrase(opts Options) (string, error) {
+	if opts.Words < MinWords || opts.Words > MaxWords {
+		return "", ErrInvalidWords
+	}
+
+	sep := opts.Separator
+	if sep == "" {
+		sep = "-"
+	}
+
+	words := make([]string, opts.Words)
+	for i := range words {
+		n, err := randInt(len(wordlist))
+		if err != nil {
+			return "", err
+		}
+		words[i] = wordlist[n]
+	}
+
+	return strings.Join(words, sep), nil
+}
+
+// classes returns the enabled character sets, minus ambiguous characters if requested.
+func classes(opts Options) []string {
+	var sets []string
+	for _, c := range []struct {
+		on    bool
+		chars string
+	}{
+		{opts.Lower, lowerChars},
+		{opts.Upper, upperChars},
+		{opts.Digits, digitChars},
+		{opts.Symbols, symbolChars},
+	} {
+		if !c.on {
+			continue
+		}
+		chars := c.chars
+		if opts.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(ambiguousChars, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+		sets = append(sets, chars)
+	}
+	return sets
+}
+
+// pick returns a uniformly random byte of set.
+func pick(set string) (byte, error) {
+	n, err := randInt(len(set))
+	if err != nil {
+		return 0, err
+	}
+	return set[n], nil
+}
+
+// permutation returns a random permutation of [0, n) (Fisher-Yates).
+func permutation(n int) ([]int, error) {
+	p := make([]int, n)
+	for i := range p {
+		p[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		p[i], p[j] = p[j], p[i]
+	}
+	return p, nil
+}
+
+// randInt returns a uniform random int in [0, max) from crypto/rand.
+func randInt(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, err
+	}
+	return int(n.Int64()), nil
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"passportier-bot/internal/activity"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+// eventLabels are the user-facing names of logged events.
+var eventLabels = map[string]string{
+	activity.EventUnlock:          "🔓 Sessiya ochildi",
+	activity.EventUnlockFailed:    "⛔️ Noto'g'ri maxfiy so'z",
+	activity.EventLock:            "🔒 Sessiya yopildi",
+	activity.EventGet:             "🔑 Ko'rildi",
+	activity.EventList:            "📋 Ro'yxat ko'rildi",
+	activity.EventInlineQuery:     "🔎 Inline qidiruv",
+	activity.EventInlineReveal:    "💬 Inline yuborildi",
+	activity.EventRead:            "👁 Ko'rildi",
+	activity.EventUpdate:          "✏️ O'zgartirildi",
+	activity.EventDelete:          "🗑 O'chirildi",
+	activity.EventExport:          "📦 Eksport",
+	activity.EventShare:           "🔗 Havola yaratildi",
+	activity.EventEmergencyAccess: "🆘 Favqulodda kirish",
+}
+
+// HandleActivity returns the /activity command handler listing the latest
+// vault access events: /activity [count].
+func HandleActivity(db *gorm.DB) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		limit := activity.DefaultLimit
+		if args := c.Args(); len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				return c.Send(fmt.Sprintf("⚠️ Foydalanish: `/activity [soni]` (1 … %d)", activity.MaxLimit), telebot.ModeMarkdown)
+			}
+			limit = n
+		}
+
+		events, err := activity.Recent(db, c.Sender().ID, limit)
+		if err != nil {
+			return c.Send("❌ Xatolik yuz berdi.")
+		}
+		if len(events) == 0 {
+			return c.Send("📭 Hali hech qanday faollik qayd etilmagan.")
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("🕵️ *So'nggi %d ta hodisa*\n\n", len(events)))
+		for _, e := range events {
+			label, ok := eventLabels[e.Event]
+			if !ok {
+				label = e.Event
+			}
+			sb.WriteString(fmt.Sprintf("`%s` %s", e.CreatedAt.Format("01-02 15:04"), label))
+			if e.Service != "" {
+				sb.WriteString(fmt.Sprintf(" — *%s*", e.Service))
+			}
+			sb.WriteString(fmt.Sprintf(" _(%s)_\n", e.Source))
+		}
+		return c.Send(sb.String(), telebot.ModeMarkdown)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"passportier-bot/internal/security"
+
+	"gopkg.in/telebot.v3"
+)
+
+// attempt is a secret check reserved with the attempt limiter. Limiter errors
+// fail open: Redis also holds the sessions, so an outage is noticed elsewhere.
+type attempt struct {
+	b        *telebot.Bot
+	c        telebot.Context
+	limiter  *security.AttemptLimiter
+	reserved security.Attempt
+	ok       bool // Whether the limiter holds a reservation
+}
+
+// startAttempt reserves a secret check for the sender. When they must wait it
+// tells them how long and reports blocked.
+func startAttempt(b *telebot.Bot, c telebot.Context, limiter *security.AttemptLimiter) (a *attempt, blocked bool, err error) {
+	a = &attempt{b: b, c: c, limiter: limiter}
+	a.reserved, err = limiter.Acquire(context.Background(), c.Sender().ID)
+	if err != nil {
+		log.Printf("[ERROR] Attempt limiter check failed for User %d: %v", c.Sender().ID, err)
+		return a, false, nil
+	}
+	if !a.reserved.Allowed() {
+		return a, true, c.Send(fmt.Sprintf("⏳ Juda ko'p noto'g'ri urinish. %s dan keyin qayta urinib ko'ring.", formatWait(a.reserved.Wait)))
+	}
+	a.ok = true
+	return a, false, nil
+}
+
+// failed keeps the reservation as a failure (a wrong passphrase or code) and
+// alerts the account when it got locked out.
+func (a *attempt) failed() {
+	if !a.ok || !a.reserved.Result.Locked {
+		return
+	}
+
+	userID := a.c.Sender().ID
+	res := a.reserved.Result
+	log.Printf("[SECURITY] User %d locked out after %d failed attempts", userID, res.Failures)
+	alert := fmt.Sprintf("🚨 *Hisobingiz vaqtincha bloklandi*\n\n%d marta noto'g'ri maxfiy so'z yoki kod kiritildi. "+
+		"Keyingi urinish %s dan keyin mumkin.\n\n_Agar bu siz bo'lmasangiz, kimdir maxfiy so'zingizni topishga urinmoqda._",
+		res.Failures, formatWait(res.RetryAfter))
+	if _, err := a.b.Send(&telebot.User{ID: userID}, alert, telebot.ModeMarkdown); err != nil {
+		log.Printf("Warning: Failed to send lockout alert to User %d: %v", userID, err)
+	}
+}
+
+// succeeded clears the user's failure count.
+func (a *attempt) succeeded() {
+	if err := a.limiter.Reset(context.Background(), a.c.Sender().ID); err != nil {
+		log.Printf("[ERROR] Attempt limiter reset failed for User %d: %v", a.c.Sender().ID, err)
+	}
+}
+
+// cancel gives the reservation back when the secret was not actually judged,
+// e.g. a confirmation step or an internal error.
+func (a *attempt) cancel() {
+	if !a.ok {
+		return
+	}
+	if err := a.limiter.Release(context.Background(), a.c.Sender().ID, a.reserved); err != nil {
+		log.Printf("[ERROR] Attempt limiter release failed for User %d: %v", a.c.Sender().ID, err)
+	}
+}
+
+// formatWait renders a wait, rounded up to whole seconds.
+func formatWait(d time.Duration) string {
+	return formatDuration(int64(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"passportier-bot/internal/breach"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+const auditUsage = "⚠️ Foydalanish: `/audit breach`\n\n_Parollaringiz ma'lum sizib chiqishlar bazasida bor-yo'qligini tekshiradi._"
+
+// HandleAudit returns the /audit command handler. Currently only
+// "/audit breach" is supported: a k-anonymity check of every stored password.
+func HandleAudit(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager, store breach.RangeStore) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		if strings.ToLower(strings.TrimSpace(c.Message().Payload)) != "breach" {
+			return c.Send(auditUsage, telebot.ModeMarkdown)
+		}
+
+		progress, err := b.Send(c.Sender(), "🔎 Tekshirilmoqda...")
+		if err != nil {
+			return err
+		}
+
+		report, err := services.AuditBreaches(context.Background(), db, sm, c.Sender().ID, store)
+		if err != nil {
+			log.Printf("[ERROR] Breach audit failed for User %d: %v", c.Sender().ID, err)
+			_, err = b.Edit(progress, "❌ Tekshirib bo'lmadi. Sessiya ochiqligini (`/unlock`) tekshiring yoki keyinroq urinib ko'ring.", telebot.ModeMarkdown)
+			return err
+		}
+
+		_, err = b.Edit(progress, formatBreachReport(report), telebot.ModeMarkdown)
+		return err
+	}
+}
+
+// formatBreachReport renders the audit result.
+func formatBreachReport(report *services.BreachReport) string {
+	var sb strings.Builder
+	sb.WriteString("🛡 *Sizib chiqish tekshiruvi*\n\n")
+	sb.WriteString(fmt.Sprintf("Tekshirildi: %d ta parol\n", report.Checked))
+
+	if len(report.Breached) == 0 {
+		sb.WriteString("\n✅ Hech bir parol ma'lum sizib chiqishlarda topilmadi.")
+	} else {
+		sb.WriteString(fmt.Sprintf("\n⚠️ *%d ta parol sizib chiqqan:*\n", len(report.Breached)))
+		for _, e := range report.Breached {
+			sb.WriteString(fmt.Sprintf("• *%s* — %d marta\n", e.Service, e.Count))
+		}
+		sb.WriteString("\n_Bu parollarni imkon qadar tezroq almashtiring (`/generate save:xizmat`)._")
+	}
+
+	if len(report.Undecryptable) > 0 {
+		sb.WriteString(fmt.Sprintf("\n\n❌ Ochib bo'lmadi: %s", strings.Join(report.Undecryptable, ", ")))
+	}
+	sb.WriteString("\n\n_🔒 Serverga faqat SHA-1 xeshining 5 ta belgisi yuboriladi._")
+	return sb.String()
+}
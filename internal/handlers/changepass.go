@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+	"passportier-bot/internal/vault"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+const changePassUsage = "⚠️ Foydalanish: `/changepass eski_so'z yangi_so'z yangi_so'z`\n\n_Yangi so'z tasdiqlash uchun ikki marta yoziladi. So'zlarda bo'sh joy bo'lmasligi kerak._"
+
+// HandleChangePass returns the /changepass command handler.
+// It verifies the old passphrase and re-encrypts the whole vault under the new one.
+// Wrong old passphrases count towards the same limit as /unlock.
+func HandleChangePass(b *telebot.Bot, sm *security.SessionManager, db *gorm.DB, limiter *security.AttemptLimiter) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		// Private chat only
+		if c.Chat().Type != telebot.ChatPrivate {
+			return nil
+		}
+
+		// Delete message for security
+		if err := b.Delete(c.Message()); err != nil {
+			log.Println("Warning: Failed to delete changepass message:", err)
+		}
+
+		args := strings.Fields(c.Message().Payload)
+		if len(args) != 3 {
+			return c.Send(changePassUsage, telebot.ModeMarkdown)
+		}
+
+		oldPass, newPass, confirm := args[0], args[1], args[2]
+		if newPass != confirm {
+			return c.Send("❌ Yangi so'zlar mos kelmadi.")
+		}
+		if newPass == oldPass {
+			return c.Send("⚠️ Yangi so'z eskisidan farq qilishi kerak.")
+		}
+
+		at, blocked, err := startAttempt(b, c, limiter)
+		if blocked {
+			return err
+		}
+
+		ttl := sessionTTL(db, c.Sender().ID)
+		err = services.ChangePassphrase(context.Background(), db, sm, c.Sender().ID, oldPass, newPass, ttl)
+
+		var undecryptable *vault.UndecryptableError
+		switch {
+		case err == nil:
+			at.succeeded()
+			log.Printf("[SESSION] User %d changed master passphrase", c.Sender().ID)
+			return c.Send("✅ *Maxfiy so'z o'zgartirildi.*\n\nBarcha yozuvlar yangi kalit bilan qayta shifrlandi va sessiya yangilandi.", telebot.ModeMarkdown)
+		case errors.As(err, &undecryptable):
+			at.cancel()
+			return c.Send(fmt.Sprintf("❌ *Hech narsa o'zgartirilmadi.*\n\nQuyidagi yozuvlarni eski so'z bilan ochib bo'lmadi:\n• %s",
+				strings.Join(undecryptable.Services, "\n• ")), telebot.ModeMarkdown)
+		case errors.Is(err, crypto.ErrInvalidPassword):
+			at.failed()
+			return c.Send("❌ *Eski maxfiy so'z noto'g'ri.*", telebot.ModeMarkdown)
+		case errors.Is(err, vault.ErrNoDataKey):
+			at.cancel()
+			return c.Send("ℹ️ Seyf hali yaratilmagan. Avval `/unlock` qiling.", telebot.ModeMarkdown)
+		default:
+			at.cancel()
+			log.Printf("[ERROR] Change passphrase failed for User %d: %v", c.Sender().ID, err)
+			return c.Send("❌ Xatolik yuz berdi, hech narsa o'zgartirilmadi.")
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/totp"
+)
+
+// credentialLines renders credential fields as Markdown lines.
+// Secret values are wrapped in `code` so they can be copied with a tap.
+func credentialLines(cred models.Credential) []string {
+	var lines []string
+	if cred.Login != "" {
+		lines = append(lines, fmt.Sprintf("👤 `%s`", cred.Login))
+	}
+	if cred.Password != "" {
+		lines = append(lines, fmt.Sprintf("🔑 `%s`", cred.Password))
+	}
+	for _, u := range cred.URLs {
+		lines = append(lines, fmt.Sprintf("🌐 %s", u))
+	}
+	for _, f := range cred.Fields {
+		lines = append(lines, fmt.Sprintf("▫️ %s: `%s`", f.Name, f.Value))
+	}
+	if cred.TOTP != "" {
+		lines = append(lines, totpLine(cred.TOTP))
+	}
+	if cred.Notes != "" {
+		lines = append(lines, fmt.Sprintf("📝 %s", cred.Notes))
+	}
+	if meta := metadataLine(cred); meta != "" {
+		lines = append(lines, meta)
+	}
+	return lines
+}
+
+// metadataLine renders the folder and tags of a credential, or "" if it has none.
+func metadataLine(cred models.Credential) string {
+	var parts []string
+	if cred.Folder != "" {
+		parts = append(parts, "📁 "+cred.Folder)
+	}
+	for _, t := range cred.Tags {
+		parts = append(parts, "#"+t)
+	}
+	return strings.Join(parts, " ")
+}
+
+// totpLine renders the current one-time code with its remaining lifetime.
+func totpLine(secret string) string {
+	code, err := totp.Now(secret)
+	if err != nil {
+		return "🔢 ❌ _2FA kaliti noto'g'ri_"
+	}
+	return fmt.Sprintf("🔢 `%s` _(%d s)_", code.Code, code.Remaining)
+}
+
+// formatCredential renders a full credential message for a service.
+func formatCredential(service string, cred models.Credential) string {
+	return fmt.Sprintf("🔐 *%s*\n\n%s", service, strings.Join(credentialLines(cred), "\n"))
+}
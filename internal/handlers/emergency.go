@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"passportier-bot/internal/activity"
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+	"passportier-bot/internal/vault"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+const emergencyUsage = "🆘 *Favqulodda kirish*\n\n" +
+	"`/emergency` — ishonchli kontaktlar va sizga berilgan ruxsatlar\n" +
+	"`/emergency add 123456789 [kun]` — ishonchli kontakt qo'shish (kutish: 1 … 30 kun, standart 7)\n" +
+	"`/emergency remove 123456789` — kontaktni o'chirish\n" +
+	"`/emergency request 123456789` — egasining seyfiga kirish so'rash\n" +
+	"`/emergency open 123456789` — kutish muddati o'tgach seyfni ochish\n\n" +
+	"_Ega kutish muddati ichida so'rovni rad etishi mumkin. Kontakt avval botda_ `/unlock` _qilgan bo'lishi kerak._"
+
+// maxMessageLength keeps messages under Telegram's 4096 character limit.
+const maxMessageLength = 3500
+
+// HandleEmergency returns the /emergency command handler for trusted contacts.
+func HandleEmergency(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		args := c.Args()
+		if len(args) == 0 {
+			return showEmergency(c, db)
+		}
+		if len(args) < 2 || len(args) > 3 {
+			return c.Send(emergencyUsage, telebot.ModeMarkdown)
+		}
+
+		otherID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return c.Send(emergencyUsage, telebot.ModeMarkdown)
+		}
+
+		switch strings.ToLower(args[0]) {
+		case "add":
+			waitDays := vault.DefaultEmergencyWait
+			if len(args) == 3 {
+				if waitDays, err = strconv.Atoi(args[2]); err != nil {
+					return c.Send(emergencyUsage, telebot.ModeMarkdown)
+				}
+			}
+			return addEmergencyContact(b, c, db, sm, otherID, waitDays)
+		case "remove":
+			if err := vault.RemoveEmergencyContact(db, c.Sender().ID, otherID); err != nil {
+				return c.Send(emergencyErrorMessage(err), telebot.ModeMarkdown)
+			}
+			return c.Send(fmt.Sprintf("✅ `%d` endi favqulodda kirish huquqiga ega emas.", otherID), telebot.ModeMarkdown)
+		case "request":
+			return requestEmergencyAccess(b, c, db, otherID)
+		case "open":
+			return openEmergencyVault(b, c, db, sm, otherID)
+		}
+		return c.Send(emergencyUsage, telebot.ModeMarkdown)
+	}
+}
+
+// RegisterEmergencyCallbacks registers the owner's reject button.
+func RegisterEmergencyCallbacks(b *telebot.Bot, db *gorm.DB) {
+	b.Handle(&telebot.InlineButton{Unique: "emergency_reject"}, func(c telebot.Context) error {
+		id, err := strconv.ParseUint(c.Data(), 10, 64)
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "❌ Xatolik"})
+		}
+
+		contact, err := vault.RejectEmergencyAccess(db, c.Sender().ID, uint(id))
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "❌ So'rov topilmadi"})
+		}
+
+		notice := fmt.Sprintf("⛔️ `%d` favqulodda kirish so'rovingizni rad etdi.", contact.OwnerID)
+		if _, err := b.Send(&telebot.User{ID: contact.ContactID}, notice, telebot.ModeMarkdown); err != nil {
+			log.Printf("Warning: Failed to notify emergency contact %d: %v", contact.ContactID, err)
+		}
+
+		_, err = b.Edit(c.Message(), fmt.Sprintf("⛔️ `%d` ning so'rovi rad etildi.", contact.ContactID), telebot.ModeMarkdown)
+		return err
+	})
+}
+
+// showEmergency lists the user's trusted contacts and the vaults they can request.
+func showEmergency(c telebot.Context, db *gorm.DB) error {
+	userID := c.Sender().ID
+	contacts, err := vault.EmergencyContacts(db, userID)
+	if err != nil {
+		return c.Send("❌ Xatolik yuz berdi.")
+	}
+	grants, err := vault.EmergencyGrants(db, userID)
+	if err != nil {
+		return c.Send("❌ Xatolik yuz berdi.")
+	}
+
+	now := time.Now()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🆘 *Favqulodda kirish*\n\n🆔 Sizning ID: `%d`\n\n", userID))
+
+	sb.WriteString("*Ishonchli kontaktlaringiz:*\n")
+	if len(contacts) == 0 {
+		sb.WriteString("_Yo'q_\n")
+	}
+	for i := range contacts {
+		sb.WriteString(fmt.Sprintf("• `%d` — %d kun, %s\n", contacts[i].ContactID, contacts[i].WaitDays, emergencyStateLabel(&contacts[i], now)))
+	}
+
+	sb.WriteString("\n*Sizni kontakt qilib tanlaganlar:*\n")
+	if len(grants) == 0 {
+		sb.WriteString("_Yo'q_\n")
+	}
+	for i := range grants {
+		sb.WriteString(fmt.Sprintf("• `%d` — %s\n", grants[i].OwnerID, emergencyStateLabel(&grants[i], now)))
+	}
+
+	sb.WriteString("\n`/emergency help` — buyruqlar")
+	return c.Send(sb.String(), telebot.ModeMarkdown)
+}
+
+// addEmergencyContact nominates a contact and tells them about it.
+func addEmergencyContact(b *telebot.Bot, c telebot.Context, db *gorm.DB, sm *security.SessionManager, contactID int64, waitDays int) error {
+	if err := services.AddEmergencyContact(context.Background(), db, sm, c.Sender().ID, contactID, waitDays); err != nil {
+		log.Printf("[ERROR] Emergency contact add failed for User %d: %v", c.Sender().ID, err)
+		return c.Send(emergencyErrorMessage(err), telebot.ModeMarkdown)
+	}
+
+	notice := fmt.Sprintf("🆘 `%d` sizni ishonchli kontakt qilib tanladi.\n\n"+
+		"Favqulodda holatda `/emergency request %d` yuboring — %d kundan keyin, agar ega rad etmasa, seyf ochiladi.",
+		c.Sender().ID, c.Sender().ID, waitDays)
+	if _, err := b.Send(&telebot.User{ID: contactID}, notice, telebot.ModeMarkdown); err != nil {
+		log.Printf("Warning: Failed to notify emergency contact %d: %v", contactID, err)
+	}
+
+	return c.Send(fmt.Sprintf("✅ `%d` ishonchli kontakt qilib qo'shildi. Kutish muddati: %d kun.", contactID, waitDays), telebot.ModeMarkdown)
+}
+
+// requestEmergencyAccess starts the waiting period and asks the owner to reject it if unexpected.
+func requestEmergencyAccess(b *telebot.Bot, c telebot.Context, db *gorm.DB, ownerID int64) error {
+	contact, err := vault.RequestEmergencyAccess(db, c.Sender().ID, ownerID, time.Now())
+	if err != nil {
+		return c.Send(emergencyErrorMessage(err), telebot.ModeMarkdown)
+	}
+	grantAt := contact.GrantAt.Format("2006-01-02 15:04")
+
+	markup := &telebot.ReplyMarkup{}
+	markup.Inline(markup.Row(markup.Data("⛔️ Rad etish", "emergency_reject", strconv.FormatUint(uint64(contact.ID), 10))))
+	notice := fmt.Sprintf("🆘 *Favqulodda kirish so'rovi*\n\n`%d` seyfingizga kirishni so'radi.\n"+
+		"Agar rad etmasangiz, ruxsat %s da avtomatik beriladi.", c.Sender().ID, grantAt)
+	if _, err := b.Send(&telebot.User{ID: ownerID}, notice, &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, ReplyMarkup: markup}); err != nil {
+		log.Printf("Warning: Failed to notify vault owner %d: %v", ownerID, err)
+	}
+
+	return c.Send(fmt.Sprintf("⏳ So'rov yuborildi. Ega rad etmasa, %s dan keyin `/emergency open %d` bilan oching.", grantAt, ownerID), telebot.ModeMarkdown)
+}
+
+// openEmergencyVault shows the owner's entries to a granted contact, hidden after 30 seconds.
+func openEmergencyVault(b *telebot.Bot, c telebot.Context, db *gorm.DB, sm *security.SessionManager, ownerID int64) error {
+	items, err := services.EmergencyAccess(context.Background(), db, sm, c.Sender().ID, ownerID)
+	if err != nil {
+		log.Printf("[ERROR] Emergency access failed for User %d Owner %d: %v", c.Sender().ID, ownerID, err)
+		return c.Send(emergencyErrorMessage(err), telebot.ModeMarkdown)
+	}
+	// Logged in the owner's activity, since it is their vault being read
+	activity.Record(db, ownerID, activity.EventEmergencyAccess, activity.SourceChat, "")
+	if len(items) == 0 {
+		return c.Send("📭 Seyf bo'sh.")
+	}
+
+	notice := fmt.Sprintf("🆘 `%d` favqulodda kirish orqali seyfingizni ochdi.", c.Sender().ID)
+	if _, err := b.Send(&telebot.User{ID: ownerID}, notice, telebot.ModeMarkdown); err != nil {
+		log.Printf("Warning: Failed to notify vault owner %d: %v", ownerID, err)
+	}
+
+	var chunks []string
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🆘 *%d* seyfi\n\n", ownerID))
+	for i := range items {
+		var item strings.Builder
+		writeListItem(&item, "🔹", &items[i])
+		if sb.Len()+item.Len() > maxMessageLength {
+			chunks = append(chunks, sb.String())
+			sb.Reset()
+		}
+		sb.WriteString(item.String())
+	}
+	chunks = append(chunks, sb.String())
+
+	for _, chunk := range chunks {
+		sentMsg, err := b.Send(c.Sender(), chunk, telebot.ModeMarkdown)
+		if err != nil {
+			return err
+		}
+		scheduleListExpiration(b, sentMsg)
+	}
+	return nil
+}
+
+// emergencyStateLabel describes a request's state at now.
+func emergencyStateLabel(contact *models.EmergencyContact, now time.Time) string {
+	switch vault.EmergencyStatus(contact, now) {
+	case vault.EmergencyPending:
+		return fmt.Sprintf("⏳ so'ralgan, %s da ochiladi", contact.GrantAt.Format("2006-01-02 15:04"))
+	case vault.EmergencyGranted:
+		return "🔓 ruxsat berilgan"
+	}
+	return "💤 so'rov yo'q"
+}
+
+// emergencyErrorMessage maps emergency access errors to user messages.
+func emergencyErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, vault.ErrEmergencyWait):
+		return fmt.Sprintf("⚠️ Kutish muddati %d … %d kun bo'lishi kerak.", vault.MinEmergencyWait, vault.MaxEmergencyWait)
+	case errors.Is(err, vault.ErrEmergencySelf):
+		return "⚠️ O'zingizni kontakt qilib tanlab bo'lmaydi."
+	case errors.Is(err, vault.ErrEmergencyNotFound):
+		return "❌ Bunday ishonchli kontakt topilmadi."
+	case errors.Is(err, vault.ErrEmergencyPending):
+		return "⏳ Ruxsat hali berilmagan. Avval `/emergency request` yuboring va kutish muddati o'tishini kuting."
+	case errors.Is(err, vault.ErrNoPublicKey):
+		return "⚠️ Bu foydalanuvchi hali botda `/unlock` qilmagan."
+	}
+	return "❌ Bajarib bo'lmadi. Sessiya ochiqligini tekshiring: `/unlock`"
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/vault"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+const expireUsage = "⚠️ Foydalanish:\n" +
+	"`/expire google 90` — har 90 kunda almashtirish\n" +
+	"`/expire google 2026-12-31` — shu sanagacha\n" +
+	"`/expire google off` — o'chirish\n" +
+	"`/expire` — muddatlar ro'yxati"
+
+// HandleExpire returns the /expire command handler that sets rotation
+// intervals and deadlines. Deadlines are plaintext metadata, so no unlock is needed.
+func HandleExpire(b *telebot.Bot, db *gorm.DB) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		args := strings.Fields(c.Message().Payload)
+		if len(args) == 0 {
+			return sendDeadlines(c, db)
+		}
+		if len(args) < 2 {
+			return c.Send(expireUsage, telebot.ModeMarkdown)
+		}
+
+		value := args[len(args)-1]
+		service := strings.Join(args[:len(args)-1], " ")
+
+		entry, err := vault.FindEntry(db, vault.Personal(c.Sender().ID), service)
+		if err != nil {
+			return c.Send(lookupErrorMessage(err), telebot.ModeMarkdown)
+		}
+
+		now := time.Now()
+		var msg string
+		switch {
+		case strings.EqualFold(value, "off"):
+			err = vault.SetRotation(db, entry.ID, 0, now)
+			msg = fmt.Sprintf("🔕 *%s* uchun muddat o'chirildi.", entry.Service)
+		case isDate(value):
+			at, _ := time.ParseInLocation("2006-01-02", value, time.Local)
+			if !at.After(now) {
+				return c.Send("⚠️ Sana kelajakda bo'lishi kerak.")
+			}
+			err = vault.SetExpiry(db, entry.ID, &at)
+			msg = fmt.Sprintf("⌛ *%s* parolini %s gacha almashtirish kerak.", entry.Service, value)
+		default:
+			days, convErr := strconv.Atoi(value)
+			if convErr != nil || days <= 0 {
+				return c.Send(expireUsage, telebot.ModeMarkdown)
+			}
+			err = vault.SetRotation(db, entry.ID, days, now)
+			msg = fmt.Sprintf("🔁 *%s* parolini har %d kunda almashtirish kerak. Keyingi muddat: %s.",
+				entry.Service, days, now.AddDate(0, 0, days).Format("2006-01-02"))
+		}
+
+		if err != nil {
+			log.Printf("[ERROR] Expire failed for User %d Service %s: %v", c.Sender().ID, entry.Service, err)
+			return c.Send("❌ Saqlashda xatolik yuz berdi.")
+		}
+		return c.Send(msg+"\n\n_Muddatdan oldin eslatma yuboriladi._", telebot.ModeMarkdown)
+	}
+}
+
+// sendDeadlines lists the user's entries that have a deadline, soonest first.
+func sendDeadlines(c telebot.Context, db *gorm.DB) error {
+	var entries []models.PasswordEntry
+	if err := db.Where("user_id = ? AND expires_at IS NOT NULL", c.Sender().ID).
+		Order("expires_at ASC").Find(&entries).Error; err != nil {
+		return c.Send("❌ Xatolik yuz berdi.")
+	}
+	if len(entries) == 0 {
+		return c.Send(expireUsage, telebot.ModeMarkdown)
+	}
+
+	now := time.Now()
+	var sb strings.Builder
+	sb.WriteString("⌛ *Almashtirish muddatlari*\n\n")
+	for i := range entries {
+		e := &entries[i]
+		sb.WriteString(fmt.Sprintf("%s *%s* — %s", expiryMarker(e, now), e.Service, e.ExpiresAt.Format("2006-01-02")))
+		if e.RotationDays > 0 {
+			sb.WriteString(fmt.Sprintf(" _(har %d kunda)_", e.RotationDays))
+		}
+		sb.WriteString("\n")
+	}
+	return c.Send(sb.String(), telebot.ModeMarkdown)
+}
+
+// expiryMarker is the icon shown next to an entry for its deadline state.
+func expiryMarker(entry *models.PasswordEntry, now time.Time) string {
+	switch vault.EntryExpiry(entry, now, vault.DefaultReminderLead) {
+	case vault.ExpiryOverdue:
+		return "⏰"
+	case vault.ExpiryDueSoon:
+		return "⌛"
+	case vault.ExpiryOK:
+		return "📅"
+	}
+	return ""
+}
+
+// isDate reports whether s is a YYYY-MM-DD date.
+func isDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
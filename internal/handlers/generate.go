@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"passportier-bot/internal/activity"
+	"passportier-bot/internal/generator"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+	"passportier-bot/internal/vault"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+const generateUsage = "⚠️ Noto'g'ri parametr.\n\n" +
+	"Misollar:\n" +
+	"`/generate` — 20 belgili parol\n" +
+	"`/generate 32 -symbols -ambiguous`\n" +
+	"`/generate words 6` — so'zlardan iborat parol (4–12 so'z)\n" +
+	"`/generate 24 save:github` — yaratib, darhol saqlash"
+
+// HandleGenerate returns the /generate command handler for secure password generation.
+// Options: length, "words" (passphrase mode), -lower/-upper/-digits/-symbols to drop a
+// class, -ambiguous to drop look-alike characters, and save:<service> to store it.
+func HandleGenerate(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		if err := b.Delete(c.Message()); err != nil {
+			log.Println("Warning: Failed to delete generate message:", err)
+		}
+
+		opts, saveTo, err := parseGenerateArgs(c.Args())
+		if err != nil {
+			return c.Send(generateUsage, telebot.ModeMarkdown)
+		}
+
+		password, err := generator.Generate(opts)
+		if err != nil {
+			return c.Send(generateUsage, telebot.ModeMarkdown)
+		}
+
+		originalText := fmt.Sprintf("🎲 *Yangi parol* (~%.0f bit)\n\n`%s`", generator.Entropy(opts), password)
+
+		if saveTo != "" {
+			if err := services.SetServicePassword(context.Background(), db, sm, c.Sender().ID, saveTo, password); err != nil {
+				if errors.Is(err, vault.ErrForbidden) {
+					return c.Send("👁 Bu umumiy seyfda faqat ko'rish huquqingiz bor.")
+				}
+				log.Printf("[ERROR] Generate save failed for User %d: %v", c.Sender().ID, err)
+				return c.Send("🔒 Saqlab bo'lmadi. Sessiya ochiqligini tekshiring: `/unlock`", telebot.ModeMarkdown)
+			}
+			activity.Record(db, c.Sender().ID, activity.EventUpdate, activity.SourceChat, saveTo)
+			originalText += fmt.Sprintf("\n\n✅ *%s* ga saqlandi.", saveTo)
+		}
+
+		msgText := fmt.Sprintf("%s\n\n⏱ _Yashirilishiga 30 soniya qoldi..._", originalText)
+		sentMsg, err := b.Send(c.Sender(), msgText, telebot.ModeMarkdown)
+		if err != nil {
+			return err
+		}
+
+		services.ScheduleCountdown(b, sentMsg, originalText)
+		return nil
+	}
+}
+
+// parseGenerateArgs converts /generate arguments into generator options.
+func parseGenerateArgs(args []string) (opts generator.Options, saveTo string, err error) {
+	opts = generator.DefaultOptions()
+	count := 0
+
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		switch {
+		case arg == "":
+		case strings.HasPrefix(arg, "save:"):
+			saveTo = strings.TrimSpace(strings.TrimPrefix(arg, "save:"))
+		case arg == "words":
+			opts.Words = generator.DefaultWords
+		case arg == "-lower":
+			opts.Lower = false
+		case arg == "-upper":
+			opts.Upper = false
+		case arg == "-digits":
+			opts.Digits = false
+		case arg == "-symbols":
+			opts.Symbols = false
+		case arg == "-ambiguous":
+			opts.ExcludeAmbiguous = true
+		default:
+			n, convErr := strconv.Atoi(arg)
+			if convErr != nil {
+				return opts, "", convErr
+			}
+			count = n
+		}
+	}
+
+	if count > 0 {
+		if opts.Words > 0 {
+			opts.Words = count
+		} else {
+			opts.Length = count
+		}
+	}
+
+	return opts, saveTo, nil
+}
@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 
+	"passportier-bot/internal/activity"
 	"passportier-bot/internal/security"
 	"passportier-bot/internal/services"
+	"passportier-bot/internal/vault"
 
 	"gopkg.in/telebot.v3"
 	"gorm.io/gorm"
@@ -26,13 +29,30 @@ func HandleGet(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot
 			return c.Send("⚠️ Qaysi xizmatni qidiryapsiz? Misol: /get google")
 		}
 
-		decrypted, err := services.GetPassword(context.Background(), db, sm, c.Sender().ID, serviceName)
+		entry, cred, err := services.GetPassword(context.Background(), db, sm, c.Sender().ID, serviceName)
+		var amb *vault.AmbiguousError
+		if errors.As(err, &amb) {
+			return sendChoices(c, amb)
+		}
 		if err != nil {
 			log.Printf("[ERROR] Get failed for User %d Service %s: %v", c.Sender().ID, serviceName, err)
 			return c.Send("❌ Topilmadi yoki sessiya yopiq. `/unlock` ni tekshiring.", telebot.ModeMarkdown)
 		}
+		activity.Record(db, c.Sender().ID, activity.EventGet, activity.SourceChat, entry.Service)
+
+		if cred.TOTP == "" {
+			return c.Send(formatCredential(entry.Service, cred), telebot.ModeMarkdown)
+		}
+
+		// 2FA codes roll over, so keep the message live until it hides
+		render := func() string { return formatCredential(entry.Service, cred) }
+		sentMsg, err := b.Send(c.Sender(), fmt.Sprintf("%s\n\n⏱ _Yashirilishiga 30 soniya qoldi..._", render()), telebot.ModeMarkdown)
+		if err != nil {
+			return err
+		}
 
-		return c.Send(fmt.Sprintf("🔑 *%s*\n\n%s", serviceName, decrypted), telebot.ModeMarkdown)
+		services.ScheduleLiveCountdown(b, sentMsg, render)
+		return nil
 	}
 }
 
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"passportier-bot/internal/health"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+// HandleHealth returns the /health command handler.
+// An optional argument sets the stale threshold in months: /health 6
+func HandleHealth(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		staleMonths := health.DefaultStaleMonths
+		if arg := strings.TrimSpace(c.Message().Payload); arg != "" {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return c.Send("⚠️ Foydalanish: `/health` yoki `/health 6` (oylar soni)", telebot.ModeMarkdown)
+			}
+			staleMonths = n
+		}
+
+		report, err := services.VaultHealth(context.Background(), db, sm, c.Sender().ID, staleMonths)
+		if err != nil {
+			log.Printf("[ERROR] Health failed for User %d: %v", c.Sender().ID, err)
+			return c.Send("🔒 Sessiya yopiq. `/unlock [so'z]` buyrug'ini yuboring.", telebot.ModeMarkdown)
+		}
+
+		return c.Send(formatHealthReport(report, staleMonths), telebot.ModeMarkdown)
+	}
+}
+
+// formatHealthReport renders the vault health report.
+func formatHealthReport(report *health.Report, staleMonths int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🩺 *Seyf holati* (%d ta yozuv)\n", report.Total))
+
+	if report.Healthy() {
+		sb.WriteString("\n✅ Hammasi joyida: zaif, takroriy yoki eskirgan parollar yo'q.")
+		return sb.String()
+	}
+
+	if len(report.Weak) > 0 {
+		sb.WriteString(fmt.Sprintf("\n⚠️ *Zaif parollar (%d):*\n", len(report.Weak)))
+		for _, w := range report.Weak {
+			sb.WriteString(fmt.Sprintf("• *%s* — %d/4 %s\n", w.Service, w.Score, weaknessLabel(w.Feedback)))
+		}
+	}
+
+	if len(report.Reused) > 0 {
+		sb.WriteString(fmt.Sprintf("\n♻️ *Takroriy parollar (%d guruh):*\n", len(report.Reused)))
+		for _, group := range report.Reused {
+			sb.WriteString(fmt.Sprintf("• %s\n", strings.Join(group, ", ")))
+		}
+	}
+
+	if len(report.Stale) > 0 {
+		sb.WriteString(fmt.Sprintf("\n🕰 *%d oydan beri o'zgarmagan (%d):*\n", staleMonths, len(report.Stale)))
+		for _, s := range report.Stale {
+			sb.WriteString(fmt.Sprintf("• *%s* — %s\n", s.Service, s.ChangedAt.Format("2006-01-02")))
+		}
+	}
+
+	if len(report.Undecryptable) > 0 {
+		sb.WriteString(fmt.Sprintf("\n❌ *Ochib bo'lmadi (%d):*\n", len(report.Undecryptable)))
+		sb.WriteString(fmt.Sprintf("• %s\n", strings.Join(report.Undecryptable, ", ")))
+	}
+
+	sb.WriteString("\n_💡 Yangi parol: `/generate save:xizmat`_")
+	return sb.String()
+}
+
+// weaknessLabel explains a strength.Estimate feedback code.
+func weaknessLabel(feedback string) string {
+	switch feedback {
+	case "common":
+		return "(ko'p ishlatiladigan parol)"
+	case "sequence":
+		return "(ketma-ketlik)"
+	case "repeat":
+		return "(takrorlangan belgilar)"
+	case "keyboard":
+		return "(klaviatura qatori)"
+	case "year":
+		return "(yil)"
+	case "short":
+		return "(juda qisqa)"
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+	"passportier-bot/internal/vault"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+// HandleHistory returns the /history command handler listing past versions of an entry.
+func HandleHistory(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		// Delete message for security
+		if err := b.Delete(c.Message()); err != nil {
+			log.Println("Warning: Failed to delete history message:", err)
+		}
+
+		serviceName := parseServiceName(c)
+		if serviceName == "" {
+			return c.Send("⚠️ Qaysi xizmat tarixini ko'rmoqchisiz? Misol: /history google")
+		}
+
+		entry, items, err := services.GetHistory(context.Background(), db, sm, c.Sender().ID, serviceName)
+		if err != nil {
+			log.Printf("[ERROR] History failed for User %d Service %s: %v", c.Sender().ID, serviceName, err)
+			return c.Send(lookupErrorMessage(err), telebot.ModeMarkdown)
+		}
+
+		if len(items) == 0 {
+			return c.Send(fmt.Sprintf("🕘 *%s* uchun oldingi versiyalar yo'q.", entry.Service), telebot.ModeMarkdown)
+		}
+
+		var sb strings.Builder
+		markup := &telebot.ReplyMarkup{}
+		var btns []telebot.Btn
+
+		sb.WriteString(fmt.Sprintf("🕘 *%s* — oldingi versiyalar\n\n", entry.Service))
+		for i, item := range items {
+			idx := i + 1
+			sb.WriteString(fmt.Sprintf("%d. _%s_", idx, item.CreatedAt.Format("2006-01-02 15:04")))
+			if item.Service != entry.Service {
+				sb.WriteString(fmt.Sprintf(" (nomi: %s)", item.Service))
+			}
+			sb.WriteString("\n")
+
+			if !item.Decrypted {
+				sb.WriteString("   └ ❌ _xato_\n\n")
+				continue
+			}
+			for _, line := range credentialLines(item.Credential) {
+				sb.WriteString(fmt.Sprintf("   └ %s\n", line))
+			}
+			sb.WriteString("\n")
+
+			btns = append(btns, markup.Data(fmt.Sprintf("♻️ %d", idx), "history_restore", strconv.FormatUint(uint64(item.VersionID), 10)))
+		}
+		sb.WriteString("_♻️ Versiyani tiklash uchun raqamni bosing_\n")
+		sb.WriteString("_⏰ 30 soniyadan so'ng yashiriladi_")
+
+		var rows []telebot.Row
+		for i := 0; i < len(btns); i += 5 {
+			end := i + 5
+			if end > len(btns) {
+				end = len(btns)
+			}
+			rows = append(rows, markup.Row(btns[i:end]...))
+		}
+		markup.Inline(rows...)
+
+		sentMsg, err := b.Send(c.Sender(), sb.String(), markup, telebot.ModeMarkdown)
+		if err != nil {
+			return err
+		}
+
+		scheduleListExpiration(b, sentMsg)
+		return nil
+	}
+}
+
+// RegisterHistoryCallbacks registers the version restore callback handler.
+func RegisterHistoryCallbacks(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) {
+	b.Handle(&telebot.InlineButton{Unique: "history_restore"}, func(c telebot.Context) error {
+		versionID, err := strconv.ParseUint(c.Data(), 10, 64)
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "Invalid option"})
+		}
+
+		entry, err := services.RestoreVersion(context.Background(), db, sm, c.Sender().ID, uint(versionID))
+		if err != nil {
+			log.Printf("[ERROR] Restore failed for User %d: %v", c.Sender().ID, err)
+			if errors.Is(err, vault.ErrForbidden) || errors.Is(err, vault.ErrNotMember) {
+				return c.Respond(&telebot.CallbackResponse{Text: vaultErrorMessage(err)})
+			}
+			if errors.Is(err, vault.ErrVersionUnreadable) {
+				return c.Respond(&telebot.CallbackResponse{Text: "❌ Bu versiyani joriy kalit bilan ochib bo'lmaydi, uni tiklab bo'lmaydi."})
+			}
+			return c.Respond(&telebot.CallbackResponse{Text: "❌ Tiklab bo'lmadi. Sessiyani tekshiring."})
+		}
+
+		if err := c.Edit(fmt.Sprintf("♻️ *%s* tanlangan versiyaga qaytarildi.", entry.Service), telebot.ModeMarkdown); err != nil {
+			log.Printf("Warning: Failed to edit history message: %v", err)
+		}
+		return c.Respond(&telebot.CallbackResponse{Text: "Tiklandi"})
+	})
+}
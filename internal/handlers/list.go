@@ -2,15 +2,18 @@ package handlers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
 	"time"
 
-	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/activity"
 	"passportier-bot/internal/models"
 	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
 	"passportier-bot/internal/vault"
 
 	"gopkg.in/telebot.v3"
@@ -22,41 +25,159 @@ const (
 )
 
 // HandleList returns the /list command handler with pagination support.
+// Optional arguments filter the listing: /list folder:work, /list tag:bank or /list #bank.
 func HandleList(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
 	return func(c telebot.Context) error {
 		if err := b.Delete(c.Message()); err != nil {
 			log.Println("Warning: Failed to delete list message:", err)
 		}
 
-		return showListPage(b, c, db, sm, 0)
+		return showListPage(b, c, db, sm, 0, parseListFilter(c.Args()))
 	}
 }
 
-// RegisterListCallbacks registers pagination callback handlers.
+// RegisterListCallbacks registers pagination and folder browsing callback handlers.
 func RegisterListCallbacks(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) {
 	b.Handle(&telebot.InlineButton{Unique: "list_page"}, func(c telebot.Context) error {
-		page, _ := strconv.Atoi(c.Data())
-		return showListPage(b, c, db, sm, page)
+		page, ref := decodeListState(c.Data())
+		return showListPage(b, c, db, sm, page, ref)
 	})
 
 	b.Handle(&telebot.InlineButton{Unique: "list_refresh"}, func(c telebot.Context) error {
-		return showListPage(b, c, db, sm, 0)
+		_, ref := decodeListState(c.Data())
+		return showListPage(b, c, db, sm, 0, ref)
 	})
+
+	b.Handle(&telebot.InlineButton{Unique: "list_folders"}, func(c telebot.Context) error {
+		return showFolders(b, c, sm, db)
+	})
+}
+
+// listRef names the folder and tag a listing is filtered by. Callback data
+// carries short digests, which fit Telegram's 64-byte limit whatever the names
+// look like; /list arguments and buttons from older messages carry the names.
+// Either form is resolved against the vault's own folder and tag names.
+type listRef struct {
+	Folder string
+	Tag    string
+}
+
+// listRefDigestLen is the number of hex characters of a folder or tag digest.
+const listRefDigestLen = 12
+
+// parseListFilter reads folder:/tag:/#tag arguments of /list.
+func parseListFilter(args []string) listRef {
+	var ref listRef
+	for _, arg := range args {
+		lower := strings.ToLower(arg)
+		switch {
+		case strings.HasPrefix(lower, "folder:"):
+			ref.Folder = arg[len("folder:"):]
+		case strings.HasPrefix(lower, "tag:"):
+			ref.Tag = arg[len("tag:"):]
+		case strings.HasPrefix(arg, "#"):
+			ref.Tag = arg[1:]
+		}
+	}
+	return ref
+}
+
+// filterDigest is the callback form of a folder or tag name; matching is
+// case-insensitive, so the name is lowercased first.
+func filterDigest(name string) string {
+	if name == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(name)))
+	return hex.EncodeToString(sum[:])[:listRefDigestLen]
 }
 
-// showListPage displays a paginated list of secrets.
-func showListPage(b *telebot.Bot, c telebot.Context, db *gorm.DB, sm *security.SessionManager, page int) error {
-	userKey, err := sm.GetSession(context.Background(), c.Sender().ID)
+// refMatches reports whether a reference names the given folder or tag.
+func refMatches(ref, name string) bool {
+	return strings.EqualFold(ref, name) || ref == filterDigest(name)
+}
+
+// resolve turns the reference into a filter with the real names found among
+// items. ok is false when a referenced folder or tag no longer exists.
+func (r listRef) resolve(items []vault.Item) (filter vault.Filter, ok bool) {
+	for _, it := range items {
+		if r.Folder != "" && filter.Folder == "" && it.Credential.Folder != "" && refMatches(r.Folder, it.Credential.Folder) {
+			filter.Folder = it.Credential.Folder
+		}
+		for _, tag := range it.Credential.Tags {
+			if r.Tag != "" && filter.Tag == "" && refMatches(r.Tag, tag) {
+				filter.Tag = tag
+			}
+		}
+	}
+	return filter, (r.Folder == "" || filter.Folder != "") && (r.Tag == "" || filter.Tag != "")
+}
+
+// encodeListState packs page and filter into callback data ("page|folder|tag"),
+// with folder and tag as digests.
+func encodeListState(page int, filter vault.Filter) string {
+	return strings.Join([]string{strconv.Itoa(page), filterDigest(filter.Folder), filterDigest(filter.Tag)}, "|")
+}
+
+// decodeListState is the inverse of encodeListState.
+// Plain page numbers from older messages are still accepted.
+func decodeListState(data string) (int, listRef) {
+	parts := strings.SplitN(data, "|", 3)
+	page, _ := strconv.Atoi(parts[0])
+
+	var ref listRef
+	if len(parts) == 3 {
+		ref.Folder, ref.Tag = parts[1], parts[2]
+	}
+	return page, ref
+}
+
+// filterItems keeps the decrypted items that pass the filter.
+func filterItems(items []vault.Item, filter vault.Filter) []vault.Item {
+	var out []vault.Item
+	for _, it := range items {
+		if it.Decrypted && filter.Match(it.Credential) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// showListPage displays a paginated list of secrets with favorites pinned on top.
+func showListPage(b *telebot.Bot, c telebot.Context, db *gorm.DB, sm *security.SessionManager, page int, ref listRef) error {
+	scope, userKey, _, err := services.ResolveScope(context.Background(), db, sm, c.Sender().ID)
 	if err != nil {
 		return c.Send("🔒 Sessiya yopiq. `/unlock [so'z]` buyrug'ini yuboring.", telebot.ModeMarkdown)
 	}
 
-	entries, err := vault.ListEntries(db, c.Sender().ID)
-	if err != nil || len(entries) == 0 {
+	items, err := vault.ListCredentials(db, scope, userKey, vault.Filter{})
+	if err != nil || len(items) == 0 {
 		return c.Send("📭 Saqlangan ma'lumotlar yo'q.")
 	}
 
-	totalPages := (len(entries) + itemsPerPage - 1) / itemsPerPage
+	filter, ok := ref.resolve(items)
+	if !filter.IsEmpty() {
+		items = filterItems(items, filter)
+	}
+	if !ok || len(items) == 0 {
+		return c.Send("📭 Bu filtr bo'yicha hech narsa topilmadi.")
+	}
+	activity.Record(db, c.Sender().ID, activity.EventList, activity.SourceChat, "")
+
+	// Favorites are pinned on every page; only the rest is paginated
+	var favorites, rest []vault.Item
+	for _, it := range items {
+		if it.Entry.Favorite {
+			favorites = append(favorites, it)
+		} else {
+			rest = append(rest, it)
+		}
+	}
+
+	totalPages := (len(rest) + itemsPerPage - 1) / itemsPerPage
+	if totalPages == 0 {
+		totalPages = 1
+	}
 	if page >= totalPages {
 		page = totalPages - 1
 	}
@@ -66,12 +187,11 @@ func showListPage(b *telebot.Bot, c telebot.Context, db *gorm.DB, sm *security.S
 
 	start := page * itemsPerPage
 	end := start + itemsPerPage
-	if end > len(entries) {
-		end = len(entries)
+	if end > len(rest) {
+		end = len(rest)
 	}
 
-	pageEntries := entries[start:end]
-	msgText, keyboard := buildPageContent(pageEntries, userKey, page, totalPages, start)
+	msgText, keyboard := buildPageContent(scopeTitle(db, scope), favorites, rest[start:end], filter, page, totalPages, start)
 
 	opts := &telebot.SendOptions{
 		ParseMode:   telebot.ModeMarkdown,
@@ -92,42 +212,64 @@ func showListPage(b *telebot.Bot, c telebot.Context, db *gorm.DB, sm *security.S
 	return nil
 }
 
+// showFolders replaces the list with a keyboard of the user's folders.
+func showFolders(b *telebot.Bot, c telebot.Context, sm *security.SessionManager, db *gorm.DB) error {
+	scope, userKey, _, err := services.ResolveScope(context.Background(), db, sm, c.Sender().ID)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "🔒 Sessiya yopiq"})
+	}
+
+	items, err := vault.ListCredentials(db, scope, userKey, vault.Filter{})
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "❌ Xatolik"})
+	}
+
+	markup := &telebot.ReplyMarkup{}
+	var rows []telebot.Row
+	for _, f := range vault.Folders(items) {
+		label := fmt.Sprintf("📁 %s (%d)", f.Name, f.Count)
+		rows = append(rows, markup.Row(markup.Data(label, "list_page", encodeListState(0, vault.Filter{Folder: f.Name}))))
+	}
+	rows = append(rows, markup.Row(markup.Data("📋 Hammasi", "list_refresh")))
+	markup.Inline(rows...)
+
+	text := "📁 *Papkalar*\n\nPapkani tanlang:"
+	if len(rows) == 1 {
+		text = "📁 *Papkalar*\n\n_Hali papkalar yo'q. Mini App yoki_ `/folder` _orqali qo'shing._"
+	}
+
+	_, err = b.Edit(c.Message(), text, &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, ReplyMarkup: markup})
+	return err
+}
+
 // buildPageContent creates message and keyboard for current page.
-func buildPageContent(entries []models.PasswordEntry, userKey string, page, totalPages, startIdx int) (string, *telebot.ReplyMarkup) {
-	cm := crypto.NewCryptoManager()
+// Favorites are rendered in a pinned section above the paginated entries.
+func buildPageContent(title string, favorites, items []vault.Item, filter vault.Filter, page, totalPages, startIdx int) (string, *telebot.ReplyMarkup) {
 	markup := &telebot.ReplyMarkup{}
 	var rows []telebot.Row
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("📋 *Sizning ma'lumotlaringiz* (sahifa %d/%d)\n\n", page+1, totalPages))
-
-	for i, entry := range entries {
-		decrypted, err := cm.Decrypt(entry.EncryptedData, userKey)
-		idx := startIdx + i + 1
+	sb.WriteString(fmt.Sprintf("📋 *%s* (sahifa %d/%d)\n", title, page+1, totalPages))
+	if filter.Folder != "" {
+		sb.WriteString(fmt.Sprintf("📁 Papka: *%s*\n", filter.Folder))
+	}
+	if filter.Tag != "" {
+		sb.WriteString(fmt.Sprintf("🏷 Teg: *#%s*\n", filter.Tag))
+	}
+	sb.WriteString("\n")
 
-		if err != nil {
-			sb.WriteString(fmt.Sprintf("%d. *%s*: ❌ _xato_\n", idx, entry.Service))
-			continue
+	if len(favorites) > 0 {
+		sb.WriteString("⭐ *Sevimlilar*\n")
+		for i := range favorites {
+			writeListItem(&sb, "⭐", &favorites[i])
 		}
-
-		// Format entry with copyable code block
-		sb.WriteString(fmt.Sprintf("%d. *%s*\n", idx, entry.Service))
-
-		// Split value into words for separate copy buttons
-		words := strings.Fields(decrypted)
-		if len(words) >= 2 {
-			// Multiple words - show each on new line
-			for j, word := range words {
-				sb.WriteString(fmt.Sprintf("   └ `%s`", word))
-				if j < len(words)-1 {
-					sb.WriteString("\n")
-				}
-			}
-		} else {
-			// Single value
-			sb.WriteString(fmt.Sprintf("   └ `%s`", decrypted))
+		if len(items) > 0 {
+			sb.WriteString("━━━━━━━━━━\n\n")
 		}
-		sb.WriteString("\n\n")
+	}
+
+	for i := range items {
+		writeListItem(&sb, fmt.Sprintf("%d.", startIdx+i+1), &items[i])
 	}
 
 	sb.WriteString("_💡 Nusxa olish uchun `kod` ustiga bosing_\n")
@@ -138,25 +280,60 @@ func buildPageContent(entries []models.PasswordEntry, userKey string, page, tota
 		var navBtns []telebot.Btn
 
 		if page > 0 {
-			navBtns = append(navBtns, markup.Data("◀️ Oldingi", "list_page", strconv.Itoa(page-1)))
+			navBtns = append(navBtns, markup.Data("◀️ Oldingi", "list_page", encodeListState(page-1, filter)))
 		}
 
-		navBtns = append(navBtns, markup.Data(fmt.Sprintf("📄 %d/%d", page+1, totalPages), "list_refresh"))
+		navBtns = append(navBtns, markup.Data(fmt.Sprintf("📄 %d/%d", page+1, totalPages), "list_refresh", encodeListState(0, filter)))
 
 		if page < totalPages-1 {
-			navBtns = append(navBtns, markup.Data("Keyingi ▶️", "list_page", strconv.Itoa(page+1)))
+			navBtns = append(navBtns, markup.Data("Keyingi ▶️", "list_page", encodeListState(page+1, filter)))
 		}
 
 		rows = append(rows, markup.Row(navBtns...))
 	}
 
+	// Folder browsing; a filtered view offers a way back to everything
+	if filter.IsEmpty() {
+		rows = append(rows, markup.Row(markup.Data("📁 Papkalar", "list_folders")))
+	} else {
+		rows = append(rows, markup.Row(
+			markup.Data("📁 Papkalar", "list_folders"),
+			markup.Data("✖️ Filtrni olib tashlash", "list_refresh"),
+		))
+	}
+
 	// Refresh button
-	rows = append(rows, markup.Row(markup.Data("🔄 Yangilash", "list_refresh")))
+	rows = append(rows, markup.Row(markup.Data("🔄 Yangilash", "list_refresh", encodeListState(0, filter))))
 
 	markup.Inline(rows...)
 	return sb.String(), markup
 }
 
+// expiryNote flags overdue and soon-due entries in listings.
+func expiryNote(entry *models.PasswordEntry, now time.Time) string {
+	switch vault.EntryExpiry(entry, now, vault.DefaultReminderLead) {
+	case vault.ExpiryOverdue:
+		return " ⏰ _muddati o'tgan_"
+	case vault.ExpiryDueSoon:
+		return " ⌛ _muddati yaqin_"
+	}
+	return ""
+}
+
+// writeListItem renders one entry with copyable code blocks, one field per line.
+func writeListItem(sb *strings.Builder, marker string, item *vault.Item) {
+	if !item.Decrypted {
+		sb.WriteString(fmt.Sprintf("%s *%s*: ❌ _xato_\n", marker, item.Entry.Service))
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("%s *%s*%s\n", marker, item.Entry.Service, expiryNote(&item.Entry, time.Now())))
+	for _, line := range credentialLines(item.Credential) {
+		sb.WriteString(fmt.Sprintf("   └ %s\n", line))
+	}
+	sb.WriteString("\n")
+}
+
 // scheduleListExpiration hides list message after 30 seconds.
 func scheduleListExpiration(b *telebot.Bot, msg *telebot.Message) {
 	go func(m *telebot.Message) {
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"testing"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/vault"
+)
+
+func TestListStateRoundTrip(t *testing.T) {
+	items := []vault.Item{
+		{Credential: models.Credential{Folder: "Ish | Хорошая длинная папка для проектов", Tags: []string{"банк", "a|b"}}, Decrypted: true},
+		{Credential: models.Credential{Folder: "home"}, Decrypted: true},
+	}
+	filter := vault.Filter{Folder: items[0].Credential.Folder, Tag: "a|b"}
+
+	data := encodeListState(3, filter)
+	// Telegram allows 64 bytes including the "\f<unique>|" prefix
+	if n := len("\flist_refresh|" + data); n > 64 {
+		t.Fatalf("callback data is %d bytes: %q", n, data)
+	}
+
+	page, ref := decodeListState(data)
+	got, ok := ref.resolve(items)
+	if page != 3 || !ok || got != filter {
+		t.Fatalf("decoded page %d filter %+v ok %v, want 3 %+v", page, got, ok, filter)
+	}
+}
+
+func TestListRefResolve(t *testing.T) {
+	items := []vault.Item{{Credential: models.Credential{Folder: "Work", Tags: []string{"bank"}}, Decrypted: true}}
+
+	tests := []struct {
+		name   string
+		ref    listRef
+		want   vault.Filter
+		wantOK bool
+	}{
+		{"empty", listRef{}, vault.Filter{}, true},
+		{"plain name from /list", listRef{Folder: "work"}, vault.Filter{Folder: "Work"}, true},
+		{"digest", listRef{Tag: filterDigest("BANK")}, vault.Filter{Tag: "bank"}, true},
+		{"gone folder", listRef{Folder: filterDigest("old")}, vault.Filter{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.ref.resolve(items)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("resolve = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
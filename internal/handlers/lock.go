@@ -4,14 +4,16 @@ import (
 	"context"
 	"log"
 
+	"passportier-bot/internal/activity"
 	"passportier-bot/internal/security"
 
 	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
 )
 
 // HandleLock returns the /lock command handler for manual session termination.
 // This allows users to instantly close their session for security.
-func HandleLock(b *telebot.Bot, sm *security.SessionManager) telebot.HandlerFunc {
+func HandleLock(b *telebot.Bot, sm *security.SessionManager, db *gorm.DB) telebot.HandlerFunc {
 	return func(c telebot.Context) error {
 		// Private chat only
 		if c.Chat().Type != telebot.ChatPrivate {
@@ -35,6 +37,7 @@ func HandleLock(b *telebot.Bot, sm *security.SessionManager) telebot.HandlerFunc
 
 		if existed {
 			log.Printf("[SESSION] User %d manually locked session", userID)
+			activity.Record(db, userID, activity.EventLock, activity.SourceChat, "")
 			return c.Send("🔒 *Sessiya yopildi.*\n\nSizning seyfingiz qulflandi. Qayta ochish uchun `/unlock` buyrug'ini yuboring.", telebot.ModeMarkdown)
 		}
 
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+// clearArg removes a folder or all tags when passed instead of a value.
+const clearArg = "-"
+
+// HandleFavorite returns the /fav command handler that pins or unpins an entry.
+func HandleFavorite(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		serviceName := parseServiceName(c)
+		if serviceName == "" {
+			return c.Send("⚠️ Qaysi xizmatni sevimlilarga qo'shasiz? Misol: /fav google")
+		}
+
+		entry, favorite, err := services.ToggleFavorite(context.Background(), db, sm, c.Sender().ID, serviceName)
+		if err != nil {
+			log.Printf("[ERROR] Favorite failed for User %d Service %s: %v", c.Sender().ID, serviceName, err)
+			return c.Send(lookupErrorMessage(err), telebot.ModeMarkdown)
+		}
+
+		if favorite {
+			return c.Send(fmt.Sprintf("⭐ *%s* sevimlilarga qo'shildi.", entry.Service), telebot.ModeMarkdown)
+		}
+		return c.Send(fmt.Sprintf("☆ *%s* sevimlilardan olib tashlandi.", entry.Service), telebot.ModeMarkdown)
+	}
+}
+
+// HandleFolder returns the /folder command handler: /folder <service> <folder>, or "-" to clear.
+func HandleFolder(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		args := strings.SplitN(strings.TrimSpace(c.Message().Payload), " ", 2)
+		if len(args) != 2 || strings.TrimSpace(args[1]) == "" {
+			return c.Send("⚠️ Foydalanish: `/folder google Ish`\n\n_Papkadan chiqarish:_ `/folder google -`", telebot.ModeMarkdown)
+		}
+
+		folder := strings.TrimSpace(args[1])
+		if folder == clearArg {
+			folder = ""
+		}
+
+		entry, err := services.SetFolder(context.Background(), db, sm, c.Sender().ID, args[0], folder)
+		if err != nil {
+			log.Printf("[ERROR] Folder failed for User %d Service %s: %v", c.Sender().ID, args[0], err)
+			return c.Send(lookupErrorMessage(err), telebot.ModeMarkdown)
+		}
+
+		if folder == "" {
+			return c.Send(fmt.Sprintf("📁 *%s* papkadan chiqarildi.", entry.Service), telebot.ModeMarkdown)
+		}
+		return c.Send(fmt.Sprintf("📁 *%s* → *%s* papkasiga ko'chirildi.", entry.Service, folder), telebot.ModeMarkdown)
+	}
+}
+
+// HandleTags returns the /tags command handler: /tags <service> tag1,tag2, or "-" to clear.
+func HandleTags(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		args := strings.SplitN(strings.TrimSpace(c.Message().Payload), " ", 2)
+		if len(args) != 2 || strings.TrimSpace(args[1]) == "" {
+			return c.Send("⚠️ Foydalanish: `/tags google ish,pochta`\n\n_Teglarni o'chirish:_ `/tags google -`", telebot.ModeMarkdown)
+		}
+
+		var tags []string
+		if raw := strings.TrimSpace(args[1]); raw != clearArg {
+			tags = strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' || r == '#' })
+		}
+
+		entry, err := services.SetTags(context.Background(), db, sm, c.Sender().ID, args[0], tags)
+		if err != nil {
+			log.Printf("[ERROR] Tags failed for User %d Service %s: %v", c.Sender().ID, args[0], err)
+			return c.Send(lookupErrorMessage(err), telebot.ModeMarkdown)
+		}
+
+		if len(tags) == 0 {
+			return c.Send(fmt.Sprintf("🏷 *%s* teglari o'chirildi.", entry.Service), telebot.ModeMarkdown)
+		}
+		return c.Send(fmt.Sprintf("🏷 *%s* teglari yangilandi.", entry.Service), telebot.ModeMarkdown)
+	}
+}
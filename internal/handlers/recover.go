@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+	"passportier-bot/internal/vault"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+const recoverUsage = "🧯 *Tiklash kodlari*\n\n" +
+	"`/recover KOD yangi_so'z yangi_so'z` — maxfiy so'zni kod orqali tiklash\n" +
+	"`/recover new` — yangi kodlar yaratish (eskilari bekor bo'ladi)\n\n" +
+	"_Har bir kod faqat bir marta ishlaydi._"
+
+// HandleRecover returns the /recover command handler: it resets the master
+// passphrase with a one-time recovery code, or issues a new set of codes.
+// Wrong codes count towards the same limit as /unlock.
+func HandleRecover(b *telebot.Bot, sm *security.SessionManager, db *gorm.DB, limiter *security.AttemptLimiter) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		// Private chat only
+		if c.Chat().Type != telebot.ChatPrivate {
+			return nil
+		}
+
+		args := strings.Fields(c.Message().Payload)
+		switch {
+		case len(args) == 0:
+			left, err := vault.RemainingRecoveryCodes(db, c.Sender().ID)
+			if err != nil {
+				return c.Send("❌ Xatolik yuz berdi.")
+			}
+			return c.Send(fmt.Sprintf("%s\n\n🔢 Qolgan kodlar: %d", recoverUsage, left), telebot.ModeMarkdown)
+
+		case len(args) == 1 && strings.EqualFold(args[0], "new"):
+			codes, err := services.RegenerateRecoveryCodes(context.Background(), db, sm, c.Sender().ID)
+			if err != nil {
+				log.Printf("[ERROR] Recovery code generation failed for User %d: %v", c.Sender().ID, err)
+				return c.Send("🔒 Sessiya yopiq. `/unlock [so'z]` buyrug'ini yuboring.", telebot.ModeMarkdown)
+			}
+			return sendRecoveryCodes(b, c, codes)
+		}
+
+		// Delete message for security: it holds a code and a passphrase
+		if err := b.Delete(c.Message()); err != nil {
+			log.Println("Warning: Failed to delete recover message:", err)
+		}
+
+		if len(args) != 3 {
+			return c.Send(recoverUsage, telebot.ModeMarkdown)
+		}
+		code, newPass, confirm := args[0], args[1], args[2]
+		if newPass != confirm {
+			return c.Send("❌ Yangi so'zlar mos kelmadi.")
+		}
+
+		at, blocked, err := startAttempt(b, c, limiter)
+		if blocked {
+			return err
+		}
+
+		ttl := sessionTTL(db, c.Sender().ID)
+		err = services.RecoverAccount(context.Background(), db, sm, c.Sender().ID, code, newPass, ttl)
+		switch {
+		case err == nil:
+			at.succeeded()
+			log.Printf("[SESSION] User %d recovered access with a recovery code", c.Sender().ID)
+			left, _ := vault.RemainingRecoveryCodes(db, c.Sender().ID)
+			return c.Send(fmt.Sprintf("✅ *Maxfiy so'z tiklandi* va sessiya ochildi.\n\nIshlatilgan kod bekor qilindi. Qolgan kodlar: %d", left), telebot.ModeMarkdown)
+		case errors.Is(err, vault.ErrInvalidRecoveryCode):
+			at.failed()
+			return c.Send("❌ *Kod noto'g'ri yoki allaqachon ishlatilgan.*", telebot.ModeMarkdown)
+		default:
+			at.cancel()
+			log.Printf("[ERROR] Recovery failed for User %d: %v", c.Sender().ID, err)
+			return c.Send("❌ Xatolik yuz berdi, hech narsa o'zgartirilmadi.")
+		}
+	}
+}
+
+// recoveryCodesTTL is how long the recovery codes stay in the chat: long
+// enough to write them down, short enough not to linger in the history.
+const recoveryCodesTTL = 5 * time.Minute
+
+// sendRecoveryCodes shows freshly issued recovery codes and deletes the message
+// after recoveryCodesTTL. They are not stored anywhere, so this is the only
+// time the user sees them.
+func sendRecoveryCodes(b *telebot.Bot, c telebot.Context, codes []string) error {
+	var sb strings.Builder
+	sb.WriteString("🧯 *Tiklash kodlari*\n\n")
+	sb.WriteString("Maxfiy so'zni unutsangiz, shu kodlardan biri bilan tiklaysiz: `/recover KOD yangi_so'z yangi_so'z`\n\n")
+	for _, code := range codes {
+		sb.WriteString(fmt.Sprintf("`%s`\n", code))
+	}
+	sb.WriteString("\n⚠️ _Kodlar faqat hozir ko'rsatiladi. Ularni oflayn saqlang — bu xabar 5 daqiqadan keyin o'chiriladi._")
+
+	msg, err := b.Send(c.Recipient(), sb.String(), telebot.ModeMarkdown)
+	if err != nil {
+		return err
+	}
+	time.AfterFunc(recoveryCodesTTL, func() {
+		if err := b.Delete(msg); err != nil {
+			log.Println("Warning: Failed to delete recovery codes message:", err)
+		}
+	})
+	return nil
+}
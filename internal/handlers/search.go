@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/vault"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+// RegisterSearchCallbacks registers the handler for picking one of several matches.
+func RegisterSearchCallbacks(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) {
+	b.Handle(&telebot.InlineButton{Unique: "search_pick"}, func(c telebot.Context) error {
+		if err := b.Delete(c.Message()); err != nil {
+			log.Println("Warning: Failed to delete choices message:", err)
+		}
+		c.Respond()
+		return handleRetrieve(c, b, db, sm, c.Data())
+	})
+}
+
+// sendChoices asks the user which of the matching entries they meant. Each
+// button carries the exact service name, so picking one resolves exactly.
+func sendChoices(c telebot.Context, amb *vault.AmbiguousError) error {
+	markup := &telebot.ReplyMarkup{}
+	var rows []telebot.Row
+	for _, name := range amb.Candidates {
+		rows = append(rows, markup.Row(markup.Data("🔑 "+name, "search_pick", name)))
+	}
+	markup.Inline(rows...)
+
+	text := fmt.Sprintf("🔎 *%s* bo'yicha bir nechta yozuv topildi. Qaysi biri?", amb.Query)
+	if len(amb.Candidates) == 1 {
+		text = fmt.Sprintf("🔎 *%s* topilmadi. Shuni nazarda tutdingizmi?", amb.Query)
+	}
+	return c.Send(text, markup, telebot.ModeMarkdown)
+}
+
+// lookupErrorMessage explains a failed entry lookup, naming the candidates
+// when the service name did not identify a single entry.
+func lookupErrorMessage(err error) string {
+	var amb *vault.AmbiguousError
+	if errors.As(err, &amb) {
+		if len(amb.Candidates) == 1 {
+			return fmt.Sprintf("🔎 *%s* topilmadi. *%s* ni nazarda tutdingizmi?\n\n_To'liq nomini yozing._",
+				amb.Query, amb.Candidates[0])
+		}
+		return fmt.Sprintf("🔎 *%s* bo'yicha bir nechta yozuv topildi:\n• %s\n\n_Aniqroq nom yozing._",
+			amb.Query, strings.Join(amb.Candidates, "\n• "))
+	}
+	if errors.Is(err, vault.ErrForbidden) || errors.Is(err, vault.ErrNotMember) {
+		return vaultErrorMessage(err)
+	}
+	return "❌ Topilmadi yoki sessiya yopiq. `/unlock` ni tekshiring."
+}
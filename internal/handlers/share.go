@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"passportier-bot/internal/activity"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+	"passportier-bot/internal/vault"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+const shareUsage = "⚠️ Foydalanish: `/share wifi [muddat] [ko'rishlar]`\n\n" +
+	"Misol: `/share wifi` — 1 soat, 1 marta\n" +
+	"`/share wifi 24h 3` — 24 soat, 3 marta\n\n" +
+	"_Muddat: 5m … 168h, ko'rishlar: 1 … 5_"
+
+// HandleShare returns the /share command handler that creates a one-time link.
+// Optional trailing arguments set the lifetime (Go duration) and the view limit.
+func HandleShare(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		// Delete message for security
+		if err := b.Delete(c.Message()); err != nil {
+			log.Println("Warning: Failed to delete share message:", err)
+		}
+
+		service, ttl, views, ok := parseShareArgs(strings.Fields(c.Message().Payload))
+		if !ok {
+			return c.Send(shareUsage, telebot.ModeMarkdown)
+		}
+
+		link, err := services.ShareCredential(context.Background(), db, sm, c.Sender().ID, service, ttl, views)
+		if errors.Is(err, vault.ErrShareLimits) {
+			return c.Send(shareUsage, telebot.ModeMarkdown)
+		}
+		if err != nil {
+			log.Printf("[ERROR] Share failed for User %d Service %s: %v", c.Sender().ID, service, err)
+			return c.Send(lookupErrorMessage(err), telebot.ModeMarkdown)
+		}
+		activity.Record(db, c.Sender().ID, activity.EventShare, activity.SourceChat, link.Service)
+
+		text := fmt.Sprintf("🔗 *%s* uchun bir martalik havola:\n\n%s\n\n"+
+			"⏳ Amal qiladi: %s gacha\n👁 Ko'rishlar: %d\n\n"+
+			"_Kalit faqat havolaning # qismida — server uni ko'rmaydi. Havola ochilgach o'chadi._",
+			link.Service, link.URL, link.ExpiresAt.Format("2006-01-02 15:04"), link.MaxViews)
+
+		// No preview: a crawler must never be the one to open the link
+		_, err = b.Send(c.Sender(), text, &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, DisableWebPagePreview: true})
+		return err
+	}
+}
+
+// parseShareArgs splits "/share <service...> [ttl] [views]".
+func parseShareArgs(args []string) (service string, ttl time.Duration, views int, ok bool) {
+	ttl, views = vault.DefaultShareTTL, vault.DefaultShareViews
+
+	if n := len(args); n > 1 {
+		if v, err := strconv.Atoi(args[n-1]); err == nil {
+			views = v
+			args = args[:n-1]
+		}
+	}
+	if n := len(args); n > 1 {
+		if d, err := time.ParseDuration(args[n-1]); err == nil {
+			ttl = d
+			args = args[:n-1]
+		}
+	}
+
+	service = strings.Join(args, " ")
+	return service, ttl, views, service != ""
+}
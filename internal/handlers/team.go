@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+	"passportier-bot/internal/vault"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+const vaultUsage = "👥 *Umumiy seyflar*\n\n" +
+	"`/vault` — seyflar ro'yxati va almashtirish\n" +
+	"`/vault new Jamoa` — yangi seyf yaratish\n" +
+	"`/vault invite 123456789 [editor|viewer]` — a'zo qo'shish yoki rolini o'zgartirish\n" +
+	"`/vault remove 123456789` — a'zoni chiqarish (kalit yangilanadi)\n" +
+	"`/vault leave` — seyfdan chiqish\n" +
+	"`/vault members` — a'zolar ro'yxati\n\n" +
+	"_Buyruqlar tanlangan seyfga tegishli. Taklif qilinuvchi avval botda_ `/unlock` _qilgan bo'lishi kerak._"
+
+// HandleVault returns the /vault command handler for shared team vaults.
+func HandleVault(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		args := c.Args()
+		if len(args) == 0 {
+			return showVaults(b, c, db)
+		}
+
+		switch strings.ToLower(args[0]) {
+		case "new":
+			return createVault(c, db, sm, strings.Join(args[1:], " "))
+		case "invite":
+			return inviteMember(b, c, db, sm, args[1:])
+		case "remove":
+			if len(args) != 2 {
+				return c.Send(vaultUsage, telebot.ModeMarkdown)
+			}
+			memberID, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				return c.Send(vaultUsage, telebot.ModeMarkdown)
+			}
+			return removeMember(c, db, sm, memberID)
+		case "leave":
+			return removeMember(c, db, sm, c.Sender().ID)
+		case "members":
+			return showMembers(c, db)
+		}
+		return c.Send(vaultUsage, telebot.ModeMarkdown)
+	}
+}
+
+// RegisterVaultCallbacks registers the vault switch buttons.
+func RegisterVaultCallbacks(b *telebot.Bot, db *gorm.DB) {
+	b.Handle(&telebot.InlineButton{Unique: "vault_select"}, func(c telebot.Context) error {
+		id, err := strconv.ParseUint(c.Data(), 10, 64)
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "❌ Xatolik"})
+		}
+		if err := vault.SelectVault(db, c.Sender().ID, uint(id)); err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: vaultErrorMessage(err)})
+		}
+		if err := c.Respond(&telebot.CallbackResponse{Text: "✅ Seyf tanlandi"}); err != nil {
+			log.Println("Warning: Failed to answer vault callback:", err)
+		}
+		return showVaults(b, c, db)
+	})
+}
+
+// showVaults lists the personal vault and the user's team vaults as switch buttons.
+func showVaults(b *telebot.Bot, c telebot.Context, db *gorm.DB) error {
+	userID := c.Sender().ID
+	vaults, err := vault.UserVaults(db, userID)
+	if err != nil {
+		return c.Send("❌ Xatolik yuz berdi.")
+	}
+	active, _, err := vault.ActiveVault(db, userID)
+	if err != nil {
+		return c.Send("❌ Xatolik yuz berdi.")
+	}
+
+	markup := &telebot.ReplyMarkup{}
+	var rows []telebot.Row
+
+	label := "👤 Shaxsiy"
+	if active == nil {
+		label = "✅ " + label
+	}
+	rows = append(rows, markup.Row(markup.Data(label, "vault_select", "0")))
+	for _, v := range vaults {
+		label := fmt.Sprintf("👥 %s (%s)", v.Vault.Name, v.Role)
+		if active != nil && active.ID == v.Vault.ID {
+			label = "✅ " + label
+		}
+		rows = append(rows, markup.Row(markup.Data(label, "vault_select", strconv.FormatUint(uint64(v.Vault.ID), 10))))
+	}
+	markup.Inline(rows...)
+
+	text := fmt.Sprintf("👥 *Seyflar*\n\n🆔 Sizning ID: `%d`\n📂 Joriy seyf: *%s*\n\n"+
+		"_/list, /get, #xizmat va Mini App tanlangan seyf bilan ishlaydi._\n`/vault help` — buyruqlar",
+		userID, scopeTitleFor(active))
+
+	opts := &telebot.SendOptions{ParseMode: telebot.ModeMarkdown, ReplyMarkup: markup}
+	if c.Callback() != nil {
+		_, err = b.Edit(c.Message(), text, opts)
+		return err
+	}
+	return c.Send(text, opts)
+}
+
+// createVault creates a team vault and makes it the active one.
+func createVault(c telebot.Context, db *gorm.DB, sm *security.SessionManager, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return c.Send(vaultUsage, telebot.ModeMarkdown)
+	}
+
+	v, err := services.CreateTeamVault(context.Background(), db, sm, c.Sender().ID, name)
+	if err != nil {
+		log.Printf("[ERROR] Vault create failed for User %d: %v", c.Sender().ID, err)
+		return c.Send(vaultErrorMessage(err), telebot.ModeMarkdown)
+	}
+	if err := vault.SelectVault(db, c.Sender().ID, v.ID); err != nil {
+		log.Printf("[ERROR] Vault select failed for User %d: %v", c.Sender().ID, err)
+	}
+
+	return c.Send(fmt.Sprintf("✅ *%s* seyfi yaratildi va tanlandi.\n\nA'zo qo'shish: `/vault invite <ID> [editor|viewer]`", v.Name), telebot.ModeMarkdown)
+}
+
+// inviteMember adds a member to the active vault and notifies them.
+func inviteMember(b *telebot.Bot, c telebot.Context, db *gorm.DB, sm *security.SessionManager, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return c.Send(vaultUsage, telebot.ModeMarkdown)
+	}
+	inviteeID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return c.Send(vaultUsage, telebot.ModeMarkdown)
+	}
+	role := models.RoleEditor
+	if len(args) == 2 {
+		role = strings.ToLower(args[1])
+	}
+
+	active, err := activeVault(c, db)
+	if active == nil {
+		return err
+	}
+
+	if err := services.InviteMember(context.Background(), db, sm, c.Sender().ID, active.ID, inviteeID, role); err != nil {
+		log.Printf("[ERROR] Vault invite failed for User %d: %v", c.Sender().ID, err)
+		return c.Send(vaultErrorMessage(err), telebot.ModeMarkdown)
+	}
+
+	notice := fmt.Sprintf("👥 Sizni *%s* seyfiga qo'shishdi (%s).\n\n`/vault` orqali tanlang.", active.Name, role)
+	if _, err := b.Send(&telebot.User{ID: inviteeID}, notice, telebot.ModeMarkdown); err != nil {
+		log.Printf("Warning: Failed to notify invited user %d: %v", inviteeID, err)
+	}
+
+	return c.Send(fmt.Sprintf("✅ `%d` *%s* seyfiga qo'shildi (%s).", inviteeID, active.Name, role), telebot.ModeMarkdown)
+}
+
+// removeMember removes a member (or the sender) from the active vault.
+func removeMember(c telebot.Context, db *gorm.DB, sm *security.SessionManager, memberID int64) error {
+	active, err := activeVault(c, db)
+	if active == nil {
+		return err
+	}
+
+	if err := services.RemoveMember(context.Background(), db, sm, c.Sender().ID, active.ID, memberID); err != nil {
+		log.Printf("[ERROR] Vault remove failed for User %d: %v", c.Sender().ID, err)
+		return c.Send(vaultErrorMessage(err), telebot.ModeMarkdown)
+	}
+
+	if memberID == c.Sender().ID {
+		return c.Send(fmt.Sprintf("👋 Siz *%s* seyfidan chiqdingiz.", active.Name), telebot.ModeMarkdown)
+	}
+	return c.Send(fmt.Sprintf("✅ `%d` *%s* seyfidan chiqarildi. Seyf kaliti yangilandi.", memberID, active.Name), telebot.ModeMarkdown)
+}
+
+// showMembers lists the members of the active vault.
+func showMembers(c telebot.Context, db *gorm.DB) error {
+	active, err := activeVault(c, db)
+	if active == nil {
+		return err
+	}
+
+	members, err := vault.Members(db, active.ID, c.Sender().ID)
+	if err != nil {
+		return c.Send(vaultErrorMessage(err), telebot.ModeMarkdown)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("👥 *%s* a'zolari:\n\n", active.Name))
+	for _, m := range members {
+		sb.WriteString(fmt.Sprintf("• `%d` — %s\n", m.UserID, m.Role))
+	}
+	return c.Send(sb.String(), telebot.ModeMarkdown)
+}
+
+// activeVault returns the active team vault. When the personal vault is
+// active it replies with a hint and returns a nil vault.
+func activeVault(c telebot.Context, db *gorm.DB) (*models.Vault, error) {
+	active, _, err := vault.ActiveVault(db, c.Sender().ID)
+	if err != nil {
+		return nil, c.Send("❌ Xatolik yuz berdi.")
+	}
+	if active == nil {
+		return nil, c.Send("⚠️ Avval umumiy seyfni tanlang: `/vault`", telebot.ModeMarkdown)
+	}
+	return active, nil
+}
+
+// scopeTitle is the heading shown for the scope in listings.
+func scopeTitle(db *gorm.DB, scope vault.Scope) string {
+	if !scope.IsShared() {
+		return scopeTitleFor(nil)
+	}
+	v, err := vault.GetVault(db, scope.VaultID)
+	if err != nil {
+		return scopeTitleFor(nil)
+	}
+	return scopeTitleFor(v)
+}
+
+// scopeTitleFor names a team vault, or the personal vault when v is nil.
+func scopeTitleFor(v *models.Vault) string {
+	if v == nil {
+		return "Sizning ma'lumotlaringiz"
+	}
+	return "👥 " + v.Name
+}
+
+// vaultErrorMessage maps team vault errors to user messages.
+func vaultErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, vault.ErrNotMember):
+		return "❌ Siz bu seyf a'zosi emassiz."
+	case errors.Is(err, vault.ErrForbidden):
+		return "⛔️ Bu amal uchun huquqingiz yetarli emas."
+	case errors.Is(err, vault.ErrNoPublicKey):
+		return "⚠️ Bu foydalanuvchi hali botda `/unlock` qilmagan."
+	case errors.Is(err, vault.ErrInvalidRole):
+		return "⚠️ Rol: `editor` yoki `viewer`."
+	}
+	return "❌ Bajarib bo'lmadi. Sessiya ochiqligini tekshiring: `/unlock`"
+}
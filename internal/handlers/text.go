@@ -2,14 +2,17 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"regexp"
 	"strings"
 
+	"passportier-bot/internal/activity"
 	"passportier-bot/internal/security"
 	"passportier-bot/internal/services"
+	"passportier-bot/internal/vault"
 
 	"gopkg.in/telebot.v3"
 	"gorm.io/gorm"
@@ -74,21 +77,26 @@ func parseHashInput(text string) (service, data string) {
 
 // handleRetrieve retrieves password with countdown timer.
 func handleRetrieve(c telebot.Context, b *telebot.Bot, db *gorm.DB, sm *security.SessionManager, serviceName string) error {
-	decrypted, err := services.GetPassword(context.Background(), db, sm, c.Sender().ID, serviceName)
+	entry, cred, err := services.GetPassword(context.Background(), db, sm, c.Sender().ID, serviceName)
+	var amb *vault.AmbiguousError
+	if errors.As(err, &amb) {
+		return sendChoices(c, amb)
+	}
 	if err != nil {
 		log.Printf("[ERROR] Retrieve failed: %v", err)
 		return c.Send(fmt.Sprintf("❌ *%s* bo'yicha ma'lumot topilmadi yoki sessiya yopiq.", serviceName), telebot.ModeMarkdown)
 	}
+	activity.Record(db, c.Sender().ID, activity.EventGet, activity.SourceChat, entry.Service)
 
-	// Original text without countdown (for countdown updates)
-	originalText := fmt.Sprintf("🔑 *%s*\n\n`%s`", serviceName, decrypted)
-	msgText := fmt.Sprintf("%s\n\n⏱ _Yashirilishiga 30 soniya qoldi..._", originalText)
+	// Re-rendered on each countdown update so TOTP codes roll over
+	render := func() string { return formatCredential(entry.Service, cred) }
+	msgText := fmt.Sprintf("%s\n\n⏱ _Yashirilishiga 30 soniya qoldi..._", render())
 
 	sentMsg, err := b.Send(c.Sender(), msgText, telebot.ModeMarkdown)
 	if err != nil {
 		return err
 	}
 
-	services.ScheduleCountdown(b, sentMsg, originalText)
+	services.ScheduleLiveCountdown(b, sentMsg, render)
 	return nil
 }
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"passportier-bot/internal/activity"
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
+	"passportier-bot/internal/transfer"
+
+	"gopkg.in/telebot.v3"
+	"gorm.io/gorm"
+)
+
+const (
+	minExportPassphrase = 8               // Minimum export passphrase length
+	maxImportSize       = 5 * 1024 * 1024 // Maximum accepted import file size
+	maxPlanListed       = 15              // Services listed per section in the import summary
+)
+
+// HandleExport returns the /export command handler.
+// The vault is sent as a JSON document encrypted with the given export passphrase.
+func HandleExport(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		// Private chat only
+		if c.Chat().Type != telebot.ChatPrivate {
+			return nil
+		}
+
+		// Delete message for security
+		if err := b.Delete(c.Message()); err != nil {
+			log.Println("Warning: Failed to delete export message:", err)
+		}
+
+		passphrase := parsePassphrase(c.Text())
+		if len(passphrase) < minExportPassphrase {
+			return c.Send(fmt.Sprintf("⚠️ Eksport fayli uchun kamida %d belgili parol kiriting. Misol: `/export fayl-paroli`", minExportPassphrase), telebot.ModeMarkdown)
+		}
+
+		data, exported, failed, err := services.ExportVault(context.Background(), db, sm, c.Sender().ID, passphrase)
+		if err != nil {
+			log.Printf("[ERROR] Export failed for User %d: %v", c.Sender().ID, err)
+			return c.Send("🔒 Eksport qilib bo'lmadi. Sessiya ochiqligini tekshiring: `/unlock`", telebot.ModeMarkdown)
+		}
+		activity.Record(db, c.Sender().ID, activity.EventExport, activity.SourceChat, "")
+
+		caption := fmt.Sprintf("📦 %d ta yozuv eksport qilindi.", exported)
+		if failed > 0 {
+			caption += fmt.Sprintf("\n⚠️ %d ta yozuvni ochib bo'lmadi va ular kiritilmadi.", failed)
+		}
+		caption += "\n\nFaylni /import orqali shu parol bilan tiklash mumkin."
+
+		doc := &telebot.Document{
+			File:     telebot.FromReader(bytes.NewReader(data)),
+			FileName: fmt.Sprintf("passportier-%s.json", time.Now().Format("2006-01-02")),
+			MIME:     "application/json",
+			Caption:  caption,
+		}
+		return c.Send(doc)
+	}
+}
+
+// HandleImport returns the /import command handler, which waits for a file.
+func HandleImport(b *telebot.Bot) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		// Private chat only
+		if c.Chat().Type != telebot.ChatPrivate {
+			return nil
+		}
+
+		services.AwaitImport(c.Sender().ID)
+
+		return c.Send("📥 *Import*\n\n"+
+			"Faylni 5 daqiqa ichida yuboring. Qo'llab-quvvatlanadi:\n"+
+			"• PassPortier eksporti (izohga eksport parolini yozing)\n"+
+			"• Bitwarden JSON\n"+
+			"• KeePass XML\n"+
+			"• 1Password CSV\n"+
+			"• Chrome CSV\n\n"+
+			"_Hech narsa tasdiqlamaguningizcha saqlanmaydi._", telebot.ModeMarkdown)
+	}
+}
+
+// HandleDocument processes an import file sent after /import and shows a dry-run summary.
+func HandleDocument(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		userID := c.Sender().ID
+		if c.Chat().Type != telebot.ChatPrivate || !services.IsAwaitingImport(userID) {
+			return nil
+		}
+
+		doc := c.Message().Document
+		passphrase := strings.TrimSpace(c.Message().Caption)
+
+		// Plaintext exports must not stay in chat history
+		defer func() {
+			if err := b.Delete(c.Message()); err != nil {
+				log.Println("Warning: Failed to delete import file:", err)
+			}
+		}()
+
+		if doc.FileSize > maxImportSize {
+			return c.Send("⚠️ Fayl juda katta (maksimum 5 MB).")
+		}
+
+		reader, err := b.File(&doc.File)
+		if err != nil {
+			log.Printf("[ERROR] Import download failed: %v", err)
+			return c.Send("❌ Faylni yuklab bo'lmadi.")
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(io.LimitReader(reader, maxImportSize))
+		if err != nil {
+			return c.Send("❌ Faylni o'qib bo'lmadi.")
+		}
+
+		plan, err := services.PlanImport(context.Background(), db, sm, userID, data, passphrase)
+		if err != nil {
+			return c.Send(importErrorMessage(err), telebot.ModeMarkdown)
+		}
+
+		markup := &telebot.ReplyMarkup{}
+		if len(plan.Creates)+len(plan.Updates) > 0 {
+			markup.Inline(markup.Row(
+				markup.Data("✅ Import qilish", "import_confirm"),
+				markup.Data("❌ Bekor qilish", "import_cancel"),
+			))
+		}
+
+		return c.Send(formatPlan(plan), markup)
+	}
+}
+
+// RegisterImportCallbacks registers import confirmation callback handlers.
+func RegisterImportCallbacks(b *telebot.Bot, db *gorm.DB, sm *security.SessionManager) {
+	b.Handle(&telebot.InlineButton{Unique: "import_confirm"}, func(c telebot.Context) error {
+		written, err := services.ApplyImport(context.Background(), db, sm, c.Sender().ID)
+		if err != nil {
+			log.Printf("[ERROR] Import failed for User %d: %v", c.Sender().ID, err)
+			return c.Edit("❌ Import bajarilmadi, hech narsa o'zgarmadi. Sessiya ochiqligini tekshiring va qayta urinib ko'ring.")
+		}
+		return c.Edit(fmt.Sprintf("✅ %d ta yozuv import qilindi.", written))
+	})
+
+	b.Handle(&telebot.InlineButton{Unique: "import_cancel"}, func(c telebot.Context) error {
+		services.CancelImport(c.Sender().ID)
+		return c.Edit("🚫 Import bekor qilindi.")
+	})
+}
+
+// formatPlan renders the dry-run summary of an import as plain text: service
+// names come from the uploaded file and could break any markup around them.
+func formatPlan(plan *transfer.Plan) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📋 Import rejasi (%s)\n\n", plan.Format))
+	sb.WriteString(fmt.Sprintf("➕ Yangi: %d\n", len(plan.Creates)))
+	sb.WriteString(fmt.Sprintf("✏️ Yangilanadi: %d\n", len(plan.Updates)))
+	sb.WriteString(fmt.Sprintf("⚠️ Ziddiyatlar: %d\n", len(plan.Conflicts)))
+	sb.WriteString(fmt.Sprintf("▫️ O'zgarishsiz: %d\n", plan.Unchanged))
+
+	writeList := func(title string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf("\n%s:\n", title))
+		for i, name := range names {
+			if i == maxPlanListed {
+				sb.WriteString(fmt.Sprintf("… va yana %d ta\n", len(names)-maxPlanListed))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("• %s\n", name))
+		}
+	}
+
+	writeList("Yangi", recordNames(plan.Creates))
+	writeList("Yangilanadi", recordNames(plan.Updates))
+
+	conflicts := make([]string, 0, len(plan.Conflicts))
+	for _, cf := range plan.Conflicts {
+		conflicts = append(conflicts, fmt.Sprintf("%s — %s", cf.Service, conflictReason(cf.Reason)))
+	}
+	writeList("Ziddiyatlar (o'tkazib yuboriladi)", conflicts)
+
+	if len(plan.Creates)+len(plan.Updates) == 0 {
+		sb.WriteString("\nImport qilinadigan o'zgarish yo'q.")
+	}
+	return sb.String()
+}
+
+func recordNames(records []transfer.Record) []string {
+	names := make([]string, 0, len(records))
+	for _, r := range records {
+		names = append(names, r.Service)
+	}
+	return names
+}
+
+func conflictReason(reason string) string {
+	switch reason {
+	case transfer.ConflictDuplicate:
+		return "faylda bir necha marta uchraydi"
+	case transfer.ConflictUndecrypted:
+		return "mavjud yozuvni ochib bo'lmadi"
+	case transfer.ConflictInvalidTOTP:
+		return "2FA kaliti noto'g'ri"
+	}
+	return reason
+}
+
+// importErrorMessage maps import errors to user-facing messages.
+func importErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, transfer.ErrPassphraseRequired):
+		return "🔑 Bu PassPortier eksporti. Faylni izohiga (caption) eksport parolini yozib qayta yuboring."
+	case errors.Is(err, crypto.ErrInvalidPassword):
+		return "❌ Eksport paroli noto'g'ri. Faylni to'g'ri parol bilan qayta yuboring."
+	case errors.Is(err, transfer.ErrEncryptedBitwarden):
+		return "⚠️ Shifrlangan Bitwarden eksporti qo'llab-quvvatlanmaydi. Shifrlanmagan JSON eksportini yuboring."
+	case errors.Is(err, transfer.ErrUnknownFormat):
+		return "⚠️ Fayl formati aniqlanmadi."
+	default:
+		log.Printf("[ERROR] Import plan failed: %v", err)
+		return "❌ Faylni o'qib bo'lmadi yoki sessiya yopiq. `/unlock` ni tekshiring."
+	}
+}
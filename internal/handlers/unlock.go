@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
+	"passportier-bot/internal/activity"
+	"passportier-bot/internal/crypto"
 	"passportier-bot/internal/models"
 	"passportier-bot/internal/security"
 	"passportier-bot/internal/services"
@@ -16,7 +19,8 @@ import (
 )
 
 // HandleUnlock returns the /unlock command handler for session authentication.
-func HandleUnlock(b *telebot.Bot, sm *security.SessionManager, db *gorm.DB) telebot.HandlerFunc {
+// Wrong passphrases are rate limited per user.
+func HandleUnlock(b *telebot.Bot, sm *security.SessionManager, db *gorm.DB, limiter *security.AttemptLimiter) telebot.HandlerFunc {
 	return func(c telebot.Context) error {
 		// Private chat only
 		if c.Chat().Type != telebot.ChatPrivate {
@@ -33,23 +37,68 @@ func HandleUnlock(b *telebot.Bot, sm *security.SessionManager, db *gorm.DB) tele
 			return c.Send("⚠️ Iltimos, maxfiy so'z kiriting! Misol: `/unlock mySecretPass`", telebot.ModeMarkdown)
 		}
 
-		// Fetch user settings for TTL
-		var user models.User
-		if err := db.First(&user, "telegram_id = ?", c.Sender().ID).Error; err != nil {
-			// If user not found, use default 30 mins
-			user.SessionTTL = 1800
+		at, blocked, err := startAttempt(b, c, limiter)
+		if blocked {
+			return err
 		}
-		
-		ttl := time.Duration(user.SessionTTL) * time.Second
-		if ttl <= 0 {
-			ttl = 30 * time.Minute
+
+		ttl := sessionTTL(db, c.Sender().ID)
+
+		codes, err := services.UnlockSession(context.Background(), db, sm, c.Sender().ID, passphrase, ttl)
+		noCodes := errors.Is(err, services.ErrNoRecoveryCodes)
+		if err != nil && !noCodes {
+			if errors.Is(err, crypto.ErrInvalidPassword) {
+				activity.Record(db, c.Sender().ID, activity.EventUnlockFailed, activity.SourceChat, "")
+				at.failed()
+			} else {
+				at.cancel()
+			}
+			return c.Send(unlockErrorMessage(err), telebot.ModeMarkdown)
 		}
+		at.succeeded()
+		activity.Record(db, c.Sender().ID, activity.EventUnlock, activity.SourceChat, "")
 
-		if err := services.UnlockSession(context.Background(), sm, c.Sender().ID, passphrase, ttl); err != nil {
-			return c.Send("❌ Sessiyani ochishda xatolik yuz berdi.")
+		if err := c.Send(fmt.Sprintf("🔓 Sessiya ochildi! Kalitingiz %s davomida Redisda saqlanadi.", formatDuration(int64(ttl.Seconds())))); err != nil {
+			return err
+		}
+		if noCodes {
+			return c.Send("⚠️ *Tiklash kodlarini yaratib bo'lmadi.* Maxfiy so'zni unutsangiz, seyfni tiklab bo'lmaydi — hoziroq `/recover new` buyrug'ini yuboring.", telebot.ModeMarkdown)
 		}
+		if len(codes) > 0 {
+			return sendRecoveryCodes(b, c, codes)
+		}
+		return nil
+	}
+}
+
+// sessionTTL returns the user's configured session lifetime (default 30 mins).
+func sessionTTL(db *gorm.DB, userID int64) time.Duration {
+	// Fetch user settings for TTL
+	var user models.User
+	if err := db.First(&user, "telegram_id = ?", userID).Error; err != nil {
+		// If user not found, use default 30 mins
+		user.SessionTTL = 1800
+	}
+
+	ttl := time.Duration(user.SessionTTL) * time.Second
+	if ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+	return ttl
+}
 
-		return c.Send(fmt.Sprintf("🔓 Sessiya ochildi! Kalitingiz %s davomida Redisda saqlanadi.", formatDuration(int64(ttl.Seconds()))))
+// unlockErrorMessage maps unlock errors to user-facing messages.
+func unlockErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, crypto.ErrInvalidPassword):
+		return "❌ *Maxfiy so'z noto'g'ri.* Qayta urinib ko'ring."
+	case errors.Is(err, services.ErrConfirmPassphrase):
+		return "🆕 *Seyf hali yaratilmagan.*\n\nTasdiqlash uchun shu maxfiy so'zni 5 daqiqa ichida yana bir bor yuboring: `/unlock [so'z]`\n\n⚠️ Bu so'zni unutmang — uni faqat tiklash kodlari bilan tiklash mumkin."
+	case errors.Is(err, services.ErrPassphraseMismatch):
+		return "❌ Maxfiy so'zlar mos kelmadi. Qaytadan boshlang: `/unlock [so'z]`"
+	default:
+		log.Printf("[ERROR] Unlock failed: %v", err)
+		return "❌ Sessiyani ochishda xatolik yuz berdi."
 	}
 }
 
@@ -0,0 +1,104 @@
+// Package health analyzes a decrypted vault for weak, reused and stale passwords.
+package health
+
+import (
+	"crypto/sha256"
+	"sort"
+	"time"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/strength"
+	"passportier-bot/internal/vault"
+)
+
+// DefaultStaleMonths is how long a password may go unchanged before it is reported.
+const DefaultStaleMonths = 12
+
+// WeakScore is the highest strength score still reported as weak.
+const WeakScore = 2
+
+// WeakEntry is an entry with a guessable password.
+type WeakEntry struct {
+	Service  string
+	Score    int    // strength.Estimate score, 0-4
+	Feedback string // Main weakness (common, sequence, ...)
+}
+
+// StaleEntry is an entry whose password has not changed for longer than the
+// stale threshold.
+type StaleEntry struct {
+	Service   string
+	ChangedAt time.Time
+}
+
+// Report summarizes vault hygiene.
+type Report struct {
+	Total         int
+	Weak          []WeakEntry
+	Reused        [][]string // Groups of services sharing one password
+	Stale         []StaleEntry
+	Undecryptable []string
+}
+
+// Healthy reports whether nothing needs attention.
+func (r *Report) Healthy() bool {
+	return len(r.Weak) == 0 && len(r.Reused) == 0 && len(r.Stale) == 0 && len(r.Undecryptable) == 0
+}
+
+// Analyze builds a report from already decrypted items. Entries are stale when
+// the password was last changed more than staleMonths months before now; edits
+// that keep the password (notes, folder, favorite) do not count.
+func Analyze(items []vault.Item, now time.Time, staleMonths int) *Report {
+	report := &Report{Total: len(items)}
+	cutoff := now.AddDate(0, -staleMonths, 0)
+
+	// Passwords are grouped by digest so plaintext is not kept as a map key
+	groups := make(map[[sha256.Size]byte][]string)
+	var order [][sha256.Size]byte
+
+	for _, it := range items {
+		service := it.Entry.Service
+		if !it.Decrypted {
+			report.Undecryptable = append(report.Undecryptable, service)
+			continue
+		}
+
+		if changed := passwordChangedAt(it.Entry); changed.Before(cutoff) {
+			report.Stale = append(report.Stale, StaleEntry{Service: service, ChangedAt: changed})
+		}
+
+		password := it.Credential.Password
+		if password == "" {
+			continue
+		}
+
+		if res := strength.Estimate(password); res.Score <= WeakScore {
+			report.Weak = append(report.Weak, WeakEntry{Service: service, Score: res.Score, Feedback: res.Feedback})
+		}
+
+		key := sha256.Sum256([]byte(password))
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], service)
+	}
+
+	for _, key := range order {
+		if len(groups[key]) > 1 {
+			report.Reused = append(report.Reused, groups[key])
+		}
+	}
+
+	sort.SliceStable(report.Weak, func(i, j int) bool { return report.Weak[i].Score < report.Weak[j].Score })
+	sort.SliceStable(report.Stale, func(i, j int) bool { return report.Stale[i].ChangedAt.Before(report.Stale[j].ChangedAt) })
+	return report
+}
+
+// passwordChangedAt is when the entry's password was last set. Rows saved
+// before the column existed and missed by the backfill fall back to creation.
+func passwordChangedAt(entry models.PasswordEntry) time.Time {
+	if entry.PasswordChangedAt != nil {
+		return *entry.PasswordChangedAt
+	}
+	return entry.CreatedAt
+}
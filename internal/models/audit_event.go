@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// AuditEvent is one entry of the append-only vault access log.
+// Rows are never updated or deleted (enforced by a trigger, see
+// migrations/009_audit_events.up.sql).
+type AuditEvent struct {
+	ID        uint      `gorm:"primarykey"`
+	UserID    int64     `gorm:"index:idx_audit_events_user_created,priority:1;not null"`
+	Event     string    `gorm:"not null"` // unlock, get, api_update, ... (see activity package)
+	Source    string    `gorm:"not null"` // chat, inline, webapp, api
+	Service   string    // Entry the event concerns, empty for vault-wide events
+	CreatedAt time.Time `gorm:"index:idx_audit_events_user_created,priority:2"`
+}
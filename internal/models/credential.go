@@ -0,0 +1,30 @@
+package models
+
+// CredentialVersion is the schema version written into new credential payloads.
+const CredentialVersion = 1
+
+// Credential is the structured record serialized (as JSON) inside the
+// encrypted payload of a PasswordEntry. It is never stored in plaintext.
+type Credential struct {
+	Version  int           `json:"v"`
+	Login    string        `json:"login,omitempty"`
+	Password string        `json:"password,omitempty"`
+	URLs     []string      `json:"urls,omitempty"`
+	Notes    string        `json:"notes,omitempty"`
+	Fields   []CustomField `json:"fields,omitempty"`
+	TOTP     string        `json:"totp,omitempty"`   // otpauth:// URI or base32 secret
+	Folder   string        `json:"folder,omitempty"` // Set only when the user keeps metadata encrypted
+	Tags     []string      `json:"tags,omitempty"`   // Set only when the user keeps metadata encrypted
+}
+
+// CustomField is a user-defined name/value pair on a credential.
+type CustomField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// IsEmpty reports whether the credential carries no secret material.
+func (c Credential) IsEmpty() bool {
+	return c.Login == "" && c.Password == "" && c.Notes == "" && c.TOTP == "" &&
+		len(c.URLs) == 0 && len(c.Fields) == 0
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmergencyContact lets a trusted user reach the owner's personal vault.
+// WrappedKey is the owner's data key sealed to the contact's public key.
+// A request made at RequestedAt is granted at GrantAt unless the owner rejects it.
+type EmergencyContact struct {
+	gorm.Model
+	OwnerID     int64  `gorm:"uniqueIndex:idx_emergency_contacts_owner_contact;not null"`
+	ContactID   int64  `gorm:"uniqueIndex:idx_emergency_contacts_owner_contact;index;not null"`
+	WrappedKey  []byte `gorm:"not null"`
+	WaitDays    int    `gorm:"not null"`
+	RequestedAt *time.Time
+	GrantAt     *time.Time
+}
@@ -1,10 +1,29 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// PasswordEntry is unique per (user_id, service) among live personal rows and
+// per (vault_id, service) among live shared rows; the partial indexes are
+// created by migrations/006_shared_vaults.up.sql. Shared entries have UserID 0.
 type PasswordEntry struct {
 	gorm.Model
-	UserID        int64  `gorm:"index"`
-	Service       string
-	EncryptedData string // Base64 encoded: Salt + Nonce + Ciphertext
-}
\ No newline at end of file
+	UserID        int64  `gorm:"index;uniqueIndex:idx_password_entries_user_service,where:deleted_at IS NULL AND vault_id IS NULL"`
+	VaultID       *uint  `gorm:"uniqueIndex:idx_password_entries_vault_service,where:deleted_at IS NULL AND vault_id IS NOT NULL"` // nil = personal entry
+	Service       string `gorm:"uniqueIndex:idx_password_entries_user_service,where:deleted_at IS NULL AND vault_id IS NULL;uniqueIndex:idx_password_entries_vault_service,where:deleted_at IS NULL AND vault_id IS NOT NULL"`
+	EncryptedData string // "v2:" + base64(Nonce + Ciphertext); legacy: base64(Salt + Nonce + Ciphertext)
+	Folder        string // Plaintext folder; empty when the user keeps metadata encrypted
+	Tags          string // Plaintext comma-separated tags; empty when the user keeps metadata encrypted
+	Favorite      bool   `gorm:"default:false"` // Pinned at the top of listings
+
+	// Set only when the password value changes; staleness is measured from here
+	PasswordChangedAt *time.Time
+
+	// Rotation metadata is plaintext so reminders work while the vault is locked
+	RotationDays int        `gorm:"default:0"` // Re-arm ExpiresAt this many days after each password change; 0 = off
+	ExpiresAt    *time.Time `gorm:"index"`     // Password should be rotated by this time; nil = never
+	RemindedAt   *time.Time // Last reminder sent for the current ExpiresAt
+}
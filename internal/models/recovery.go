@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RecoveryCode is a one-time offline recovery code. The code itself is never
+// stored: CodeHash finds the row and WrappedKey is the user's recovery key
+// wrapped with an Argon2id key derived from the code and Salt.
+type RecoveryCode struct {
+	ID         uint   `gorm:"primarykey"`
+	UserID     int64  `gorm:"index;not null"`
+	CodeHash   []byte `gorm:"not null"`
+	Salt       []byte `gorm:"not null"`
+	WrappedKey []byte `gorm:"not null"`
+	CreatedAt  time.Time
+}
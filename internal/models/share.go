@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SharedSecret is a credential re-encrypted under a one-off random key for a
+// share link. The key is never stored; it exists only in the link's URL
+// fragment. The row is deleted after MaxViews views or at ExpiresAt.
+type SharedSecret struct {
+	ID         string    `gorm:"primaryKey"` // Random URL-safe identifier
+	UserID     int64     `gorm:"index;not null"`
+	Ciphertext []byte    `gorm:"not null"` // Nonce + AES-GCM ciphertext, the ID as additional data
+	MaxViews   int       `gorm:"not null;default:1"`
+	Views      int       `gorm:"not null;default:0"`
+	ExpiresAt  time.Time `gorm:"index;not null"`
+	CreatedAt  time.Time
+}
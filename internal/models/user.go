@@ -2,11 +2,25 @@ package models
 
 import "gorm.io/gorm"
 
-// User model stores the per-user salt required for Argon2id key derivation.
-// We do NOT store paswords or hashes here, only the Salt.
+// User model stores the per-user key material for envelope encryption.
+// We do NOT store paswords or hashes here, only the Salt and the wrapped data key.
 type User struct {
 	gorm.Model
-	TelegramID int64  `gorm:"uniqueIndex;not null"`
-	Salt       []byte `gorm:"not null"` // Random salt for this user
-	SessionTTL int64  `gorm:"default:1800"` // Session TTL in seconds (default 30 mins)
+	TelegramID       int64  `gorm:"uniqueIndex;not null"`
+	Salt             []byte `gorm:"not null"` // Random salt for deriving the key-encryption key
+	WrappedKey       []byte // Data-encryption key, encrypted under the passphrase-derived key
+	KeyDigest        []byte // SHA-256 of the current data key; detects session keys retired by a rotation
+	SessionTTL       int64  `gorm:"default:1800"`  // Session TTL in seconds (default 30 mins)
+	HistoryRetention int    `gorm:"default:10"`    // Versions kept per entry
+	EncryptMetadata  bool   `gorm:"default:false"` // Keep folder and tags inside the ciphertext
+
+	// X25519 key pair for receiving shared vault keys; the private key is wrapped under the data key
+	PublicKey         []byte
+	WrappedPrivateKey []byte
+	ActiveVaultID     *uint // Shared vault selected for /list, /get and the API; nil = personal
+
+	// Recovery key wrapped under the data key, and the data key wrapped under the
+	// recovery key; recovery codes wrap the recovery key (see RecoveryCode)
+	RecoveryKey        []byte
+	RecoveryWrappedKey []byte
 }
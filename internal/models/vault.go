@@ -0,0 +1,29 @@
+package models
+
+import "gorm.io/gorm"
+
+// Member roles in a shared vault.
+const (
+	RoleOwner  = "owner"  // Manages members; full access
+	RoleEditor = "editor" // Reads and writes entries
+	RoleViewer = "viewer" // Reads entries
+)
+
+// Vault is a shared team vault. Its entries are encrypted with a vault key
+// that is stored only sealed to each member's public key (see VaultMember).
+type Vault struct {
+	gorm.Model
+	Name      string `gorm:"not null"`
+	OwnerID   int64  `gorm:"index;not null"` // Telegram ID of the creator
+	KeyDigest []byte // SHA-256 of the current vault key; see User.KeyDigest
+}
+
+// VaultMember grants a user access to a vault.
+// WrappedKey is the vault key sealed to the member's public key.
+type VaultMember struct {
+	gorm.Model
+	VaultID    uint   `gorm:"uniqueIndex:idx_vault_members_vault_user;not null"`
+	UserID     int64  `gorm:"uniqueIndex:idx_vault_members_vault_user;index;not null"`
+	Role       string `gorm:"not null"`
+	WrappedKey []byte `gorm:"not null"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PasswordEntryVersion keeps a prior ciphertext of a PasswordEntry.
+// A version is written before every overwrite or rename of the entry.
+type PasswordEntryVersion struct {
+	ID            uint   `gorm:"primaryKey"`
+	EntryID       uint   `gorm:"index;not null"`
+	UserID        int64  `gorm:"index;not null"`
+	Service       string // Service name at the time of the version
+	EncryptedData string `gorm:"not null"`
+	CreatedAt     time.Time
+}
@@ -0,0 +1,119 @@
+// Package reminder sends rotation and expiry reminders for password entries.
+//
+// It reads only plaintext entry metadata (service name and deadlines), so it
+// runs in the bot process without any vault being unlocked.
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
+)
+
+// DefaultInterval is how often the scheduler looks for due entries.
+const DefaultInterval = time.Hour
+
+// maxSendAttempts is how many runs in a row may fail to deliver a reminder
+// before it is given up on, so an undeliverable one is not retried forever.
+const maxSendAttempts = 3
+
+// Notifier delivers a plain-text message to a Telegram user. Service names
+// are user input, so reminders carry no markup that they could break.
+type Notifier func(userID int64, text string) error
+
+// Scheduler periodically notifies users about entries nearing or past expiry.
+type Scheduler struct {
+	db       *gorm.DB
+	notify   Notifier
+	interval time.Duration
+	lead     time.Duration
+	now      func() time.Time
+	failures map[uint]int // Failed sends per entry ID since its last delivery
+}
+
+// NewScheduler creates a scheduler. REMINDER_INTERVAL (Go duration) and
+// REMINDER_LEAD_DAYS override the defaults.
+func NewScheduler(db *gorm.DB, notify Notifier) *Scheduler {
+	s := &Scheduler{
+		db:       db,
+		notify:   notify,
+		interval: DefaultInterval,
+		lead:     vault.DefaultReminderLead,
+		now:      time.Now,
+		failures: make(map[uint]int),
+	}
+	if d, err := time.ParseDuration(os.Getenv("REMINDER_INTERVAL")); err == nil && d > 0 {
+		s.interval = d
+	}
+	if days, err := strconv.Atoi(os.Getenv("REMINDER_LEAD_DAYS")); err == nil && days > 0 {
+		s.lead = time.Duration(days) * 24 * time.Hour
+	}
+	return s
+}
+
+// Run checks for due entries immediately and then every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("[REMINDER] Scheduler started (every %s, %s ahead)", s.interval, s.lead)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(); err != nil {
+			log.Printf("[REMINDER] Run failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends all reminders due now. A failed send is retried on the next
+// runs; after maxSendAttempts failures the entry is marked reminded anyway.
+// RunOnce must not be called concurrently.
+func (s *Scheduler) RunOnce() error {
+	now := s.now()
+	entries, err := vault.DueReminders(s.db, now, s.lead)
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if err := s.notify(entry.UserID, reminderText(entry, now)); err != nil {
+			s.failures[entry.ID]++
+			if s.failures[entry.ID] < maxSendAttempts {
+				log.Printf("[REMINDER] Failed to notify user %d: %v", entry.UserID, err)
+				continue
+			}
+			log.Printf("[REMINDER] Giving up on entry %d of user %d after %d attempts: %v", entry.ID, entry.UserID, maxSendAttempts, err)
+		}
+		delete(s.failures, entry.ID)
+		if err := vault.MarkReminded(s.db, entry.ID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reminderText renders the plain-text reminder for an entry.
+func reminderText(entry *models.PasswordEntry, now time.Time) string {
+	due := entry.ExpiresAt.Format("2006-01-02")
+	if !now.Before(*entry.ExpiresAt) {
+		return fmt.Sprintf("⏰ «%s» parolining muddati o'tdi (%s).\n\nYangi parol: /generate save:%s", entry.Service, due, entry.Service)
+	}
+
+	days := int(entry.ExpiresAt.Sub(now).Hours()/24) + 1
+	return fmt.Sprintf("⌛ «%s» parolini %d kun ichida (%s gacha) almashtirish kerak.\n\nYangi parol: /generate save:%s", entry.Service, days, due, entry.Service)
+}
@@ -43,7 +43,7 @@ func NewSecretRepository(db *gorm.DB) *SecretRepository {
 //
 //	INSERT INTO password_entries (user_id, service, encrypted_data, updated_at)
 //	VALUES ($1, $2, $3, NOW())
-//	ON CONFLICT (user_id, service)
+//	ON CONFLICT (user_id, service) WHERE deleted_at IS NULL AND vault_id IS NULL
 //	DO UPDATE SET
 //	    encrypted_data = EXCLUDED.encrypted_data,
 //	    updated_at = NOW();
@@ -56,10 +56,12 @@ func (r *SecretRepository) Upsert(ctx context.Context, userID int64, keyName, en
 		EncryptedValue: encryptedValue,
 	}
 
-	// GORM's upsert using ON CONFLICT clause
+	// GORM's upsert using ON CONFLICT clause; the predicate must match the
+	// personal partial unique index (migration 006) for Postgres to infer it
 	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "user_id"}, {Name: "service"}},
-		DoUpdates: clause.AssignmentColumns([]string{"encrypted_data", "updated_at"}),
+		Columns:     []clause.Column{{Name: "user_id"}, {Name: "service"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL AND vault_id IS NULL"}}},
+		DoUpdates:   clause.AssignmentColumns([]string{"encrypted_data", "updated_at"}),
 	}).Create(&secret).Error
 }
 
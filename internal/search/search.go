@@ -0,0 +1,207 @@
+// Package search ranks service names against a user query.
+//
+// Candidates are matched, from strongest to weakest, by exact name, name
+// prefix, word (token) prefix, substring and finally edit distance, so that
+// "git" finds both "github" and "gitlab" while "gthub" still finds "github".
+// Only an exact name or a unique prefix resolves to an entry on its own; the
+// weaker kinds are suggestions the user has to confirm.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kind is how a candidate matched the query. Higher kinds are stronger.
+type Kind int
+
+// Match kinds, weakest first.
+const (
+	KindFuzzy Kind = iota + 1
+	KindSubstring
+	KindToken
+	KindPrefix
+	KindExact
+)
+
+// String returns the kind name used in API responses.
+func (k Kind) String() string {
+	switch k {
+	case KindExact:
+		return "exact"
+	case KindPrefix:
+		return "prefix"
+	case KindToken:
+		return "token"
+	case KindSubstring:
+		return "substring"
+	case KindFuzzy:
+		return "fuzzy"
+	}
+	return "none"
+}
+
+// kindWeight separates kinds so that no tie-breaker can reorder them.
+const kindWeight = 1000
+
+// minFuzzyLen is the shortest query for which typos are tolerated.
+const minFuzzyLen = 3
+
+// Match is a ranked candidate.
+type Match struct {
+	Name  string
+	Kind  Kind
+	Score int
+}
+
+// Rank scores every candidate against query and returns the matches,
+// best first. Candidates that do not match at all are dropped.
+// Matching is case-insensitive; an empty query matches nothing.
+func Rank(query string, names []string) []Match {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return nil
+	}
+
+	var matches []Match
+	for _, name := range names {
+		if m, ok := score(q, name); ok {
+			matches = append(matches, m)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return strings.ToLower(matches[i].Name) < strings.ToLower(matches[j].Name)
+	})
+	return matches
+}
+
+// Best returns the top match when it safely identifies one entry: an exact
+// match, or the only prefix match. Token, substring and fuzzy matches are
+// never returned, since acting on a typo could disclose or change the wrong
+// entry; callers offer them as suggestions instead.
+func Best(matches []Match) (Match, bool) {
+	if len(matches) == 0 {
+		return Match{}, false
+	}
+	top := matches[0]
+	switch {
+	case top.Kind == KindExact:
+		return top, true
+	case top.Kind == KindPrefix && (len(matches) == 1 || matches[1].Kind < KindPrefix):
+		return top, true
+	}
+	return Match{}, false
+}
+
+// score matches a lowercase query against a single name.
+// Within a kind, closer candidates (shorter leftover, fewer edits) score higher.
+func score(q, name string) (Match, bool) {
+	n := strings.ToLower(name)
+	extra := len(n) - len(q)
+	if extra < 0 {
+		extra = 0
+	}
+
+	switch {
+	case n == q:
+		return Match{Name: name, Kind: KindExact, Score: int(KindExact) * kindWeight}, true
+	case strings.HasPrefix(n, q):
+		return Match{Name: name, Kind: KindPrefix, Score: int(KindPrefix)*kindWeight - extra}, true
+	case tokensMatch(q, n):
+		return Match{Name: name, Kind: KindToken, Score: int(KindToken)*kindWeight - extra}, true
+	case strings.Contains(n, q):
+		return Match{Name: name, Kind: KindSubstring, Score: int(KindSubstring)*kindWeight - extra}, true
+	}
+
+	if len([]rune(q)) < minFuzzyLen {
+		return Match{}, false
+	}
+	if d := fuzzyDistance(q, n); d <= maxDistance(q) {
+		return Match{Name: name, Kind: KindFuzzy, Score: int(KindFuzzy)*kindWeight - 100*d - extra}, true
+	}
+	return Match{}, false
+}
+
+// tokensMatch reports whether every query word is a prefix of some word of the name.
+func tokensMatch(q, n string) bool {
+	nameTokens := tokenize(n)
+	for _, qt := range tokenize(q) {
+		found := false
+		for _, nt := range nameTokens {
+			if strings.HasPrefix(nt, qt) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenize splits on anything that is not a letter or digit.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// fuzzyDistance is the smallest edit distance between the query and the
+// whole name, any of its words, or the name's prefix of the query's length
+// (so a typo at the start of a long name still counts).
+func fuzzyDistance(q, n string) int {
+	qr := []rune(q)
+	best := levenshtein(qr, []rune(n))
+
+	if nr := []rune(n); len(nr) > len(qr) {
+		if d := levenshtein(qr, nr[:len(qr)]); d < best {
+			best = d
+		}
+	}
+	for _, t := range tokenize(n) {
+		if d := levenshtein(qr, []rune(t)); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// maxDistance is the number of typos tolerated for a query of this length.
+func maxDistance(q string) int {
+	switch l := len([]rune(q)); {
+	case l <= 4:
+		return 1
+	case l <= 8:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// levenshtein computes the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
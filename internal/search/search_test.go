@@ -0,0 +1,106 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRank(t *testing.T) {
+	names := []string{"GitHub", "GitLab", "Google Mail", "my-git-server", "Bitbucket", "Gitea"}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []Match
+	}{
+		{
+			name:  "exact before typos",
+			query: "gitlab",
+			want: []Match{
+				{Name: "GitLab", Kind: KindExact, Score: 5000},
+				{Name: "Gitea", Kind: KindFuzzy, Score: 800},
+				{Name: "GitHub", Kind: KindFuzzy, Score: 800},
+			},
+		},
+		{
+			name:  "shorter prefix first",
+			query: "git",
+			want: []Match{
+				{Name: "Gitea", Kind: KindPrefix, Score: 3998},
+				{Name: "GitHub", Kind: KindPrefix, Score: 3997},
+				{Name: "GitLab", Kind: KindPrefix, Score: 3997},
+				{Name: "my-git-server", Kind: KindToken, Score: 2990},
+				{Name: "Bitbucket", Kind: KindFuzzy, Score: 894},
+			},
+		},
+		{
+			name:  "word prefix",
+			query: "mail",
+			want:  []Match{{Name: "Google Mail", Kind: KindToken, Score: 2993}},
+		},
+		{
+			name:  "substring",
+			query: "bucket",
+			want:  []Match{{Name: "Bitbucket", Kind: KindSubstring, Score: 1997}},
+		},
+		{
+			name:  "typo",
+			query: "gthub",
+			want:  []Match{{Name: "GitHub", Kind: KindFuzzy, Score: 899}},
+		},
+		{
+			name:  "short query tolerates no typos",
+			query: "gx",
+			want:  nil,
+		},
+		{
+			name:  "case and spaces ignored",
+			query: "  GITHUB ",
+			want: []Match{
+				{Name: "GitHub", Kind: KindExact, Score: 5000},
+				{Name: "GitLab", Kind: KindFuzzy, Score: 800},
+			},
+		},
+		{
+			name:  "empty query",
+			query: "",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Rank(tt.query, names); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Rank(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBest(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		names  []string
+		want   string
+		wantOK bool
+	}{
+		{name: "exact", query: "git", names: []string{"git", "github"}, want: "git", wantOK: true},
+		{name: "unique prefix", query: "gith", names: []string{"github", "gitlab"}, want: "github", wantOK: true},
+		{name: "prefix over token", query: "git", names: []string{"github", "my-git"}, want: "github", wantOK: true},
+		{name: "shared prefix", query: "git", names: []string{"github", "gitlab"}},
+		{name: "lone token match", query: "mail", names: []string{"google mail"}},
+		{name: "lone substring match", query: "bucket", names: []string{"bitbucket"}},
+		{name: "lone typo", query: "gthub", names: []string{"github"}},
+		{name: "no match", query: "aws", names: []string{"github"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Best(Rank(tt.query, tt.names))
+			if ok != tt.wantOK || got.Name != tt.want {
+				t.Fatalf("Best(%q) = %q, %v, want %q, %v", tt.query, got.Name, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
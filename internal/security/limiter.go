@@ -0,0 +1,183 @@
+package security
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Attempt limiter defaults, overridable with UNLOCK_* environment variables.
+const (
+	DefaultFreeAttempts = 3               // Failures allowed before backoff starts
+	DefaultMaxFailures  = 10              // Failures that trigger a lockout
+	DefaultBaseDelay    = 2 * time.Second // First backoff, doubled per failure
+	DefaultMaxDelay     = 5 * time.Minute // Backoff cap
+	DefaultLockout      = time.Hour       // Lockout length
+	DefaultAttemptTTL   = 24 * time.Hour  // Counters reset after a quiet day
+)
+
+// AttemptResult describes the limiter state after a failed attempt.
+type AttemptResult struct {
+	Failures   int
+	RetryAfter time.Duration // Zero when the next attempt may be made at once
+	Locked     bool          // This failure reached MaxFailures; later ones stay locked without a new alert
+}
+
+// AttemptLimiter counts failed secret checks (passphrase, recovery code) per
+// Telegram user in Redis, so every entry point and every process share one
+// counter. After FreeAttempts failures each further failure imposes an
+// exponentially growing wait; MaxFailures failures lock the user out.
+type AttemptLimiter struct {
+	client       *redis.Client
+	now          func() time.Time // Injectable clock; waits are computed from it, not from Redis TTLs
+	freeAttempts int
+	maxFailures  int
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	lockout      time.Duration
+}
+
+// NewAttemptLimiter creates a limiter with the defaults, overridden by
+// UNLOCK_FREE_ATTEMPTS, UNLOCK_MAX_FAILURES and UNLOCK_LOCKOUT when set.
+func NewAttemptLimiter(client *redis.Client) *AttemptLimiter {
+	l := &AttemptLimiter{
+		client:       client,
+		now:          time.Now,
+		freeAttempts: DefaultFreeAttempts,
+		maxFailures:  DefaultMaxFailures,
+		baseDelay:    DefaultBaseDelay,
+		maxDelay:     DefaultMaxDelay,
+		lockout:      DefaultLockout,
+	}
+	if n, err := strconv.Atoi(os.Getenv("UNLOCK_FREE_ATTEMPTS")); err == nil && n >= 0 {
+		l.freeAttempts = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("UNLOCK_MAX_FAILURES")); err == nil && n > 0 {
+		l.maxFailures = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("UNLOCK_LOCKOUT")); err == nil && d > 0 {
+		l.lockout = d
+	}
+	return l
+}
+
+// acquireScript reserves an attempt unless the user is blocked. The reserved
+// attempt is counted as a failure up front, and the delay it would impose is
+// applied at once, so concurrent checks cannot all slip through before the
+// first one's failure is recorded. Times and delays are in milliseconds.
+//
+// KEYS[1] attempt key; ARGV: now, free attempts, max failures, base delay,
+// max delay, lockout, counter TTL. Returns {wait, failures, delay, blocked_until}.
+var acquireScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local blocked = tonumber(redis.call('HGET', key, 'blocked_until_ms') or '0')
+if blocked > now then
+	return {blocked - now, 0, 0, 0}
+end
+
+local failures = redis.call('HINCRBY', key, 'failures', 1)
+local free, maxFailures = tonumber(ARGV[2]), tonumber(ARGV[3])
+local base, maxDelay, lockout, ttl = tonumber(ARGV[4]), tonumber(ARGV[5]), tonumber(ARGV[6]), tonumber(ARGV[7])
+
+local delay = 0
+if failures >= maxFailures then
+	delay = lockout
+elseif failures > free then
+	delay = base
+	for i = 2, failures - free do
+		if delay >= maxDelay then break end
+		delay = delay * 2
+	end
+	delay = math.min(delay, maxDelay)
+end
+
+local untilMs = 0
+if delay > 0 then
+	untilMs = now + delay
+	redis.call('HSET', key, 'blocked_until_ms', untilMs)
+end
+redis.call('PEXPIRE', key, math.max(ttl, delay))
+return {0, failures, delay, untilMs}
+`)
+
+// releaseScript undoes a reservation: it uncounts the failure and lifts the
+// block the reservation set, unless a later one replaced it.
+//
+// KEYS[1] attempt key; ARGV[1] blocked_until set by the reservation, 0 if none.
+var releaseScript = redis.NewScript(`
+local key = KEYS[1]
+local untilMs = tonumber(ARGV[1])
+if untilMs > 0 and tonumber(redis.call('HGET', key, 'blocked_until_ms') or '0') == untilMs then
+	redis.call('HDEL', key, 'blocked_until_ms')
+end
+if tonumber(redis.call('HGET', key, 'failures') or '0') > 0 then
+	redis.call('HINCRBY', key, 'failures', -1)
+end
+return 1
+`)
+
+// Attempt is a secret check reserved by Acquire.
+type Attempt struct {
+	Wait   time.Duration // Non-zero when refused; nothing was reserved then
+	Result AttemptResult // Limiter state if the check turns out wrong
+
+	blockedUntil int64 // Unix ms block set by this reservation, zero if none
+}
+
+// Allowed reports whether the check may go ahead.
+func (a Attempt) Allowed() bool {
+	return a.Wait <= 0
+}
+
+// Acquire atomically checks whether the user may try a secret now and, if so,
+// reserves the attempt by counting it as a failure in advance, blocking further
+// attempts for the backoff delay or, once MaxFailures is reached, for the
+// lockout period. Afterwards call Reset when the secret was right, Release
+// when the check did not happen (e.g. a setup step failed), and nothing when
+// it was wrong. The count is kept after a lockout, so each later failure locks
+// again until a success or a quiet DefaultAttemptTTL resets it; only the first
+// lockout sets Locked.
+func (l *AttemptLimiter) Acquire(ctx context.Context, userID int64) (Attempt, error) {
+	res, err := acquireScript.Run(ctx, l.client, []string{fmtAttemptKey(userID)},
+		l.now().UnixMilli(),
+		l.freeAttempts,
+		l.maxFailures,
+		l.baseDelay.Milliseconds(),
+		l.maxDelay.Milliseconds(),
+		l.lockout.Milliseconds(),
+		max(DefaultAttemptTTL, l.lockout).Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Attempt{}, err
+	}
+
+	if res[0] > 0 {
+		return Attempt{Wait: time.Duration(res[0]) * time.Millisecond}, nil
+	}
+	failures := int(res[1])
+	return Attempt{
+		Result: AttemptResult{
+			Failures:   failures,
+			RetryAfter: time.Duration(res[2]) * time.Millisecond,
+			Locked:     failures == l.maxFailures,
+		},
+		blockedUntil: res[3],
+	}, nil
+}
+
+// Release returns an allowed attempt that did not end up checking the secret.
+func (l *AttemptLimiter) Release(ctx context.Context, userID int64, a Attempt) error {
+	if !a.Allowed() {
+		return nil
+	}
+	return releaseScript.Run(ctx, l.client, []string{fmtAttemptKey(userID)}, a.blockedUntil).Err()
+}
+
+// Reset clears the user's counter after a successful attempt.
+func (l *AttemptLimiter) Reset(ctx context.Context, userID int64) error {
+	return l.client.Del(ctx, fmtAttemptKey(userID)).Err()
+}
@@ -0,0 +1,221 @@
+package security
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestLimiter returns a limiter with the default policy and a clock the
+// test controls.
+func newTestLimiter(t *testing.T) (*AttemptLimiter, *time.Time) {
+	t.Helper()
+	_, client := newTestRedis(t)
+	now := time.Unix(1_700_000_000, 0)
+
+	l := NewAttemptLimiter(client)
+	l.now = func() time.Time { return now }
+	l.freeAttempts = DefaultFreeAttempts
+	l.maxFailures = DefaultMaxFailures
+	l.lockout = DefaultLockout
+	return l, &now
+}
+
+// fail makes an attempt that turns out wrong. It fails the test on a Redis
+// error or when the attempt is refused.
+func fail(t *testing.T, l *AttemptLimiter) AttemptResult {
+	t.Helper()
+	a, err := l.Acquire(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if !a.Allowed() {
+		t.Fatalf("Acquire refused, wait %s", a.Wait)
+	}
+	return a.Result
+}
+
+// wait returns the current wait, releasing the attempt again when one was
+// allowed, and fails the test on a Redis error.
+func wait(t *testing.T, l *AttemptLimiter) time.Duration {
+	t.Helper()
+	a, err := l.Acquire(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if err := l.Release(context.Background(), 1, a); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	return a.Wait
+}
+
+func TestAttemptLimiterFreeAttempts(t *testing.T) {
+	l, _ := newTestLimiter(t)
+
+	for i := 1; i <= DefaultFreeAttempts; i++ {
+		res := fail(t, l)
+		if res.Failures != i || res.RetryAfter != 0 || res.Locked {
+			t.Fatalf("failure %d: %+v, want no delay", i, res)
+		}
+		if d := wait(t, l); d != 0 {
+			t.Fatalf("failure %d: Wait = %s, want 0", i, d)
+		}
+	}
+}
+
+func TestAttemptLimiterBackoff(t *testing.T) {
+	l, now := newTestLimiter(t)
+	l.maxFailures = 100 // Keep the lockout out of the way of the cap
+
+	for i := 0; i < DefaultFreeAttempts; i++ {
+		fail(t, l)
+	}
+
+	want := DefaultBaseDelay
+	for i := 0; i < 12; i++ {
+		res := fail(t, l)
+		if res.RetryAfter != want {
+			t.Fatalf("failure %d: RetryAfter = %s, want %s", res.Failures, res.RetryAfter, want)
+		}
+		if d := wait(t, l); d != want {
+			t.Fatalf("failure %d: Wait = %s, want %s", res.Failures, d, want)
+		}
+
+		// The wait counts down with the clock and ends when the delay has passed
+		*now = now.Add(want / 2)
+		if d := wait(t, l); d != want-want/2 {
+			t.Fatalf("failure %d: Wait after half = %s, want %s", res.Failures, d, want-want/2)
+		}
+		*now = now.Add(want)
+		if d := wait(t, l); d != 0 {
+			t.Fatalf("failure %d: Wait after delay = %s, want 0", res.Failures, d)
+		}
+
+		want = min(want*2, DefaultMaxDelay)
+	}
+}
+
+func TestAttemptLimiterLockout(t *testing.T) {
+	l, now := newTestLimiter(t)
+
+	var res AttemptResult
+	for i := 1; i <= DefaultMaxFailures; i++ {
+		res = fail(t, l)
+		if res.Locked != (i == DefaultMaxFailures) {
+			t.Fatalf("failure %d: Locked = %v", i, res.Locked)
+		}
+		*now = now.Add(res.RetryAfter)
+	}
+	if res.RetryAfter != DefaultLockout {
+		t.Fatalf("RetryAfter = %s, want %s", res.RetryAfter, DefaultLockout)
+	}
+
+	*now = now.Add(-DefaultLockout + 10*time.Minute)
+	if d := wait(t, l); d != DefaultLockout-10*time.Minute {
+		t.Fatalf("Wait = %s, want %s", d, DefaultLockout-10*time.Minute)
+	}
+}
+
+func TestAttemptLimiterAlertsOnce(t *testing.T) {
+	l, now := newTestLimiter(t)
+
+	alerts := 0
+	for i := 0; i < DefaultMaxFailures+3; i++ {
+		res := fail(t, l)
+		if res.Locked {
+			alerts++
+		}
+		if res.Failures > DefaultMaxFailures && res.RetryAfter != DefaultLockout {
+			t.Fatalf("failure %d: RetryAfter = %s, want lockout", res.Failures, res.RetryAfter)
+		}
+		*now = now.Add(res.RetryAfter)
+	}
+	if alerts != 1 {
+		t.Fatalf("alerts = %d, want 1", alerts)
+	}
+}
+
+func TestAttemptLimiterReset(t *testing.T) {
+	l, _ := newTestLimiter(t)
+
+	for i := 0; i < DefaultFreeAttempts+1; i++ {
+		fail(t, l)
+	}
+	if wait(t, l) == 0 {
+		t.Fatal("expected a wait before reset")
+	}
+
+	if err := l.Reset(context.Background(), 1); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if d := wait(t, l); d != 0 {
+		t.Fatalf("Wait after reset = %s, want 0", d)
+	}
+	if res := fail(t, l); res.Failures != 1 || res.RetryAfter != 0 {
+		t.Fatalf("first failure after reset: %+v", res)
+	}
+}
+
+func TestAttemptLimiterRelease(t *testing.T) {
+	l, _ := newTestLimiter(t)
+
+	for i := 0; i < DefaultFreeAttempts; i++ {
+		fail(t, l)
+	}
+
+	// An attempt that did not check the secret leaves no trace
+	a, err := l.Acquire(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if a.Result.RetryAfter != DefaultBaseDelay {
+		t.Fatalf("RetryAfter = %s, want %s", a.Result.RetryAfter, DefaultBaseDelay)
+	}
+	if err := l.Release(context.Background(), 1, a); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if d := wait(t, l); d != 0 {
+		t.Fatalf("Wait after release = %s, want 0", d)
+	}
+	if res := fail(t, l); res.Failures != DefaultFreeAttempts+1 {
+		t.Fatalf("Failures = %d, want %d", res.Failures, DefaultFreeAttempts+1)
+	}
+}
+
+func TestAttemptLimiterConcurrent(t *testing.T) {
+	l, _ := newTestLimiter(t)
+
+	for i := 0; i < DefaultFreeAttempts; i++ {
+		fail(t, l)
+	}
+
+	// Once the free attempts are used up, simultaneous guesses get one try
+	const guesses = 50
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a, err := l.Acquire(context.Background(), 1)
+			if err != nil {
+				t.Errorf("Acquire: %v", err)
+				return
+			}
+			if a.Allowed() {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 1 {
+		t.Fatalf("allowed = %d of %d, want 1", allowed, guesses)
+	}
+}
@@ -2,28 +2,65 @@ package security
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
 	"time"
 
+	"passportier-bot/internal/crypto"
+
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrSessionInvalid is returned when a stored session cannot be unwrapped,
+// e.g. it was written by a previous process with a different secret.
+var ErrSessionInvalid = errors.New("session invalid")
+
+// SessionManager stores session keys in Redis, wrapped under an ephemeral
+// secret that lives only in process memory. A Redis dump alone is useless.
 type SessionManager struct {
 	client *redis.Client
+	secret []byte // Generated at startup, never persisted
 }
 
 func NewSessionManager(client *redis.Client) *SessionManager {
-	return &SessionManager{client: client}
+	secret := make([]byte, crypto.KeySize)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		panic("failed to generate session secret: " + err.Error())
+	}
+	return &SessionManager{client: client, secret: secret}
 }
 
 // SetSession stores the session key with the specified TTL.
-// Zero-Knowledge: We store the passphrase in Redis (Ram) with strictly limited TTL.
+// Zero-Knowledge: The data key is wrapped under the process secret (bound to the
+// Redis key) before it leaves RAM, so RDB snapshots never contain usable keys.
 func (sm *SessionManager) SetSession(ctx context.Context, userID int64, key string, ttl time.Duration) error {
 	redisKey := fmtSessionKey(userID)
-	return sm.client.Set(ctx, redisKey, key, ttl).Err()
+	wrapped, err := crypto.EncryptWithAD([]byte(key), sm.secret, []byte(redisKey))
+	if err != nil {
+		return err
+	}
+	return sm.client.Set(ctx, redisKey, base64.StdEncoding.EncodeToString(wrapped), ttl).Err()
 }
 
-// GetSession retrieves the session key if it exists.
+// GetSession retrieves and unwraps the session key if it exists.
 func (sm *SessionManager) GetSession(ctx context.Context, userID int64) (string, error) {
 	redisKey := fmtSessionKey(userID)
-	return sm.client.Get(ctx, redisKey).Result()
+	stored, err := sm.client.Get(ctx, redisKey).Result()
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(stored)
+	if err != nil {
+		return "", ErrSessionInvalid
+	}
+
+	key, err := crypto.DecryptWithAD(wrapped, sm.secret, []byte(redisKey))
+	if err != nil {
+		return "", ErrSessionInvalid
+	}
+
+	return string(key), nil
 }
@@ -0,0 +1,97 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const testDataKey = "c2Vzc2lvbi1kYXRhLWtleS0zMi1ieXRlcy1sb25nISE="
+
+// newTestRedis starts an in-memory Redis and returns it with a client for it.
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	_, client := newTestRedis(t)
+	sm := NewSessionManager(client)
+	ctx := context.Background()
+
+	if err := sm.SetSession(ctx, 1, testDataKey, time.Minute); err != nil {
+		t.Fatalf("SetSession: %v", err)
+	}
+	got, err := sm.GetSession(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if got != testDataKey {
+		t.Fatalf("GetSession = %q, want %q", got, testDataKey)
+	}
+}
+
+func TestSessionStoredWrapped(t *testing.T) {
+	mr, client := newTestRedis(t)
+	sm := NewSessionManager(client)
+
+	if err := sm.SetSession(context.Background(), 1, testDataKey, time.Minute); err != nil {
+		t.Fatalf("SetSession: %v", err)
+	}
+
+	raw, err := mr.Get(fmtSessionKey(1))
+	if err != nil {
+		t.Fatalf("raw value: %v", err)
+	}
+	if strings.Contains(raw, testDataKey) {
+		t.Fatal("raw Redis value contains the data key")
+	}
+	if ttl := mr.TTL(fmtSessionKey(1)); ttl != time.Minute {
+		t.Fatalf("TTL = %s, want 1m", ttl)
+	}
+}
+
+func TestSessionOtherProcessSecret(t *testing.T) {
+	_, client := newTestRedis(t)
+	ctx := context.Background()
+
+	if err := NewSessionManager(client).SetSession(ctx, 1, testDataKey, time.Minute); err != nil {
+		t.Fatalf("SetSession: %v", err)
+	}
+
+	// A restarted process has a fresh secret and must not open old sessions
+	if _, err := NewSessionManager(client).GetSession(ctx, 1); !errors.Is(err, ErrSessionInvalid) {
+		t.Fatalf("GetSession err = %v, want ErrSessionInvalid", err)
+	}
+}
+
+func TestSessionCopiedToOtherUser(t *testing.T) {
+	mr, client := newTestRedis(t)
+	sm := NewSessionManager(client)
+	ctx := context.Background()
+
+	if err := sm.SetSession(ctx, 1, testDataKey, time.Minute); err != nil {
+		t.Fatalf("SetSession: %v", err)
+	}
+
+	// The wrapping is bound to the Redis key, so a copied value is useless
+	raw, err := mr.Get(fmtSessionKey(1))
+	if err != nil {
+		t.Fatalf("raw value: %v", err)
+	}
+	if err := mr.Set(fmtSessionKey(2), raw); err != nil {
+		t.Fatalf("copy value: %v", err)
+	}
+
+	if _, err := sm.GetSession(ctx, 2); !errors.Is(err, ErrSessionInvalid) {
+		t.Fatalf("GetSession err = %v, want ErrSessionInvalid", err)
+	}
+}
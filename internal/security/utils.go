@@ -10,6 +10,11 @@ func fmtSessionKey(userID int64) string {
 	return fmt.Sprintf("session:%d", userID)
 }
 
+// fmtAttemptKey formats the Redis key for a user's failed secret checks.
+func fmtAttemptKey(userID int64) string {
+	return fmt.Sprintf("attempts:%d", userID)
+}
+
 // ClearSession removes the session key immediately.
 func (sm *SessionManager) ClearSession(ctx context.Context, userID int64) error {
 	redisKey := fmtSessionKey(userID)
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"passportier-bot/internal/breach"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
+)
+
+// BreachedEntry is an entry whose password appears in known breaches.
+type BreachedEntry struct {
+	Service string
+	Count   int // Times the password was seen in breach corpora
+}
+
+// BreachReport is the result of a breach audit.
+// Undecryptable lists entries that could not be opened with the session key.
+type BreachReport struct {
+	Checked       int
+	Breached      []BreachedEntry
+	Undecryptable []string
+}
+
+// AuditBreaches checks every stored password against the breach store.
+// Only 5-character SHA-1 prefixes leave the process; see package breach.
+func AuditBreaches(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, store breach.RangeStore) (*BreachReport, error) {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found")
+	}
+
+	items, err := vault.ListCredentials(db, vault.Personal(userID), userKey, vault.Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	checker := breach.NewChecker(store)
+	report := &BreachReport{}
+	for _, it := range items {
+		if !it.Decrypted {
+			report.Undecryptable = append(report.Undecryptable, it.Entry.Service)
+			continue
+		}
+		if it.Credential.Password == "" {
+			continue
+		}
+
+		count, err := checker.Check(ctx, it.Credential.Password)
+		if err != nil {
+			return nil, fmt.Errorf("breach lookup: %w", err)
+		}
+		report.Checked++
+		if count > 0 {
+			report.Breached = append(report.Breached, BreachedEntry{Service: it.Entry.Service, Count: count})
+		}
+	}
+
+	// Most exposed first
+	sort.SliceStable(report.Breached, func(i, j int) bool {
+		return report.Breached[i].Count > report.Breached[j].Count
+	})
+	return report, nil
+}
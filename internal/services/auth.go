@@ -2,14 +2,114 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"time"
 
+	"passportier-bot/internal/crypto"
 	"passportier-bot/internal/security"
+	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
 )
 
-// UnlockSession stores the user's passphrase in Redis for the session.
-// Zero-Knowledge: The passphrase is used directly for per-encryption key derivation.
-// No salt is stored in DB; each encryption generates its own unique salt.
-func UnlockSession(ctx context.Context, sm *security.SessionManager, userID int64, passphrase string, ttl time.Duration) error {
-	return sm.SetSession(ctx, userID, passphrase, ttl)
+// UnlockSession verifies the passphrase, derives the user's data key and stores it in Redis.
+// Envelope encryption: the expensive Argon2id derivation runs once here, and every
+// entry is then encrypted with the data key. Legacy entries are migrated on the way.
+//
+// On first-run setup the vault's recovery codes are returned; they must be shown
+// to the user once. Returns crypto.ErrInvalidPassword for a wrong passphrase, and
+// ErrConfirmPassphrase / ErrPassphraseMismatch during first-run setup. If the
+// codes could not be issued the session is still opened and ErrNoRecoveryCodes
+// is returned, so the user can be told to generate them.
+func UnlockSession(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, passphrase string, ttl time.Duration) ([]string, error) {
+	var codes []string
+	var codesErr error
+	userKey, err := vault.LoadDataKey(db, userID, passphrase)
+	if errors.Is(err, vault.ErrNoDataKey) {
+		userKey, err = setupDataKey(db, userID, passphrase)
+		if err == nil {
+			if codes, codesErr = vault.GenerateRecoveryCodes(db, userID, userKey); codesErr != nil {
+				log.Printf("[VAULT] Recovery code setup failed for user %d: %v", userID, codesErr)
+				codes, codesErr = nil, ErrNoRecoveryCodes
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if n, err := vault.MigrateLegacyEntries(db, userID, passphrase, userKey); err != nil {
+		log.Printf("[VAULT] Legacy migration failed for user %d: %v", userID, err)
+	} else if n > 0 {
+		log.Printf("[VAULT] Migrated %d legacy entries and versions for user %d", n, userID)
+	}
+
+	if err := vault.RecordKeyDigest(db, userID, userKey); err != nil {
+		log.Printf("[VAULT] Key digest setup failed for user %d: %v", userID, err)
+	}
+
+	// Team vault invitations need a public key; create it on first unlock
+	if err := vault.EnsureKeyPair(db, userID, userKey); err != nil {
+		log.Printf("[VAULT] Key pair setup failed for user %d: %v", userID, err)
+	}
+
+	if err := sm.SetSession(ctx, userID, userKey, ttl); err != nil {
+		return nil, err
+	}
+	return codes, codesErr
+}
+
+// setupDataKey sets the master passphrase on first unlock.
+// Users with legacy entries prove the passphrase by decrypting one of them;
+// new users confirm it by sending it twice.
+func setupDataKey(db *gorm.DB, userID int64, passphrase string) (string, error) {
+	ok, hasLegacy, err := vault.VerifyLegacyPassphrase(db, userID, passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	if hasLegacy && !ok {
+		return "", crypto.ErrInvalidPassword
+	}
+
+	if !hasLegacy {
+		if err := confirmPassphrase(userID, passphrase); err != nil {
+			return "", err
+		}
+	}
+
+	return vault.InitDataKey(db, userID, passphrase)
+}
+
+// ChangePassphrase rotates the master passphrase and re-encrypts the vault,
+// then replaces the active session with the new data key.
+func ChangePassphrase(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, oldPassphrase, newPassphrase string, ttl time.Duration) error {
+	userKey, err := vault.ChangePassphrase(db, userID, oldPassphrase, newPassphrase)
+	if err != nil {
+		return err
+	}
+
+	return sm.SetSession(ctx, userID, userKey, ttl)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes with a fresh set.
+func RegenerateRecoveryCodes(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64) ([]string, error) {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found")
+	}
+	return vault.GenerateRecoveryCodes(db, userID, userKey)
+}
+
+// RecoverAccount sets a new passphrase with a one-time recovery code and
+// opens a session with the recovered data key.
+func RecoverAccount(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, code, newPassphrase string, ttl time.Duration) error {
+	userKey, err := vault.RecoverWithCode(db, userID, code, newPassphrase)
+	if err != nil {
+		return err
+	}
+
+	return sm.SetSession(ctx, userID, userKey, ttl)
 }
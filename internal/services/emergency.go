@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
+)
+
+// AddEmergencyContact nominates a trusted contact for the user's personal vault.
+func AddEmergencyContact(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID, contactID int64, waitDays int) error {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("session not found")
+	}
+	return vault.AddEmergencyContact(db, userID, userKey, contactID, waitDays)
+}
+
+// EmergencyAccess decrypts the owner's personal vault for a contact whose
+// request has been granted. The contact's own session must be open.
+func EmergencyAccess(ctx context.Context, db *gorm.DB, sm *security.SessionManager, contactID, ownerID int64) ([]vault.Item, error) {
+	userKey, err := sm.GetSession(ctx, contactID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found")
+	}
+
+	ownerKey, err := vault.OpenEmergencyVault(db, contactID, ownerID, userKey, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return vault.ListCredentials(db, vault.Personal(ownerID), ownerKey, vault.Filter{})
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	"passportier-bot/internal/health"
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
+)
+
+// vaultFingerprint changes whenever an entry is added, edited or deleted.
+type vaultFingerprint struct {
+	Count  int64
+	Latest time.Time
+}
+
+func (f vaultFingerprint) equal(o vaultFingerprint) bool {
+	return f.Count == o.Count && f.Latest.Equal(o.Latest)
+}
+
+// cachedHealth is a report computed for one unlocked session (RAM only).
+type cachedHealth struct {
+	keyDigest   [sha256.Size]byte // Digest of the data key the vault was decrypted with
+	fingerprint vaultFingerprint
+	staleMonths int
+	report      *health.Report
+	expires     time.Time
+}
+
+// healthCacheTTL bounds how long a report is reused: staleness moves with the
+// clock, and reports of users who never come back must not pile up.
+const healthCacheTTL = 10 * time.Minute
+
+var (
+	healthCache = make(map[int64]cachedHealth)
+	healthMu    sync.Mutex
+)
+
+// VaultHealth reports weak, reused, stale (older than staleMonths) and
+// undecryptable entries. The vault is decrypted once and the report reused
+// while the session's data key and the entries stay the same.
+func VaultHealth(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, staleMonths int) (*health.Report, error) {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found")
+	}
+
+	if staleMonths <= 0 {
+		staleMonths = health.DefaultStaleMonths
+	}
+
+	var fp vaultFingerprint
+	if err := db.Model(&models.PasswordEntry{}).Where("user_id = ?", userID).
+		Select("COUNT(*) AS count, MAX(updated_at) AS latest").Scan(&fp).Error; err != nil {
+		return nil, err
+	}
+
+	keyDigest := sha256.Sum256([]byte(userKey))
+
+	now := time.Now()
+	healthMu.Lock()
+	cached, ok := healthCache[userID]
+	healthMu.Unlock()
+	if ok && now.Before(cached.expires) && cached.keyDigest == keyDigest && cached.fingerprint.equal(fp) && cached.staleMonths == staleMonths {
+		return cached.report, nil
+	}
+
+	items, err := vault.ListCredentials(db, vault.Personal(userID), userKey, vault.Filter{})
+	if err != nil {
+		return nil, err
+	}
+	report := health.Analyze(items, now, staleMonths)
+
+	healthMu.Lock()
+	for id, c := range healthCache {
+		if !now.Before(c.expires) {
+			delete(healthCache, id)
+		}
+	}
+	healthCache[userID] = cachedHealth{
+		keyDigest:   keyDigest,
+		fingerprint: fp,
+		staleMonths: staleMonths,
+		report:      report,
+		expires:     now.Add(healthCacheTTL),
+	}
+	healthMu.Unlock()
+
+	return report, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
+)
+
+// HistoryItem is a decrypted past version of an entry.
+// Decrypted is false when the version cannot be opened with the session key.
+type HistoryItem struct {
+	VersionID  uint
+	Service    string
+	CreatedAt  time.Time
+	Credential models.Credential
+	Decrypted  bool
+}
+
+// GetHistory returns the entry matching service in the active vault and its
+// decrypted versions, newest first.
+func GetHistory(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service string) (*models.PasswordEntry, []HistoryItem, error) {
+	scope, key, _, err := ResolveScope(ctx, db, sm, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry, versions, err := vault.EntryHistory(db, scope, service)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entry, DecryptHistory(versions, key), nil
+}
+
+// DecryptHistory opens versions with the key of the vault they belong to.
+func DecryptHistory(versions []models.PasswordEntryVersion, key string) []HistoryItem {
+	items := make([]HistoryItem, 0, len(versions))
+	for _, v := range versions {
+		cred, err := vault.DecryptCredential(&models.PasswordEntry{EncryptedData: v.EncryptedData}, key)
+		items = append(items, HistoryItem{
+			VersionID:  v.ID,
+			Service:    v.Service,
+			CreatedAt:  v.CreatedAt,
+			Credential: cred,
+			Decrypted:  err == nil,
+		})
+	}
+	return items
+}
+
+// RestoreVersion restores a past version of an entry in the active vault.
+// Requires an unlocked session and write access.
+func RestoreVersion(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, versionID uint) (*models.PasswordEntry, error) {
+	scope, userKey, role, err := ResolveScope(ctx, db, sm, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !vault.CanWrite(role) {
+		return nil, vault.ErrForbidden
+	}
+
+	return vault.RestoreVersion(db, scope, versionID, userKey)
+}
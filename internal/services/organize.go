@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
+)
+
+// ToggleFavorite pins or unpins the entry matching service in the active vault.
+// Returns the entry and its new favorite state.
+func ToggleFavorite(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service string) (*models.PasswordEntry, bool, error) {
+	scope, key, role, err := ResolveScope(ctx, db, sm, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !vault.CanWrite(role) {
+		return nil, false, vault.ErrForbidden
+	}
+
+	entry, _, err := vault.RetrieveCredential(db, scope, service, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	favorite := !entry.Favorite
+	if err := vault.SetFavorite(db, scope, entry.Service, favorite); err != nil {
+		return nil, false, err
+	}
+	return entry, favorite, nil
+}
+
+// SetFolder moves the entry matching service into folder ("" removes it from any folder).
+func SetFolder(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service, folder string) (*models.PasswordEntry, error) {
+	return updateCredential(ctx, db, sm, userID, service, func(cred *models.Credential) {
+		cred.Folder = folder
+	})
+}
+
+// SetTags replaces the tags of the entry matching service (nil clears them).
+func SetTags(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service string, tags []string) (*models.PasswordEntry, error) {
+	return updateCredential(ctx, db, sm, userID, service, func(cred *models.Credential) {
+		cred.Tags = tags
+	})
+}
+
+// SetMetadataEncryption switches between plaintext and encrypted folders/tags for the user.
+func SetMetadataEncryption(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, encrypt bool) error {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("session not found")
+	}
+
+	return vault.SetMetadataEncryption(db, userID, encrypt, userKey)
+}
+
+// updateCredential decrypts the entry matching service in the active vault,
+// applies mutate and saves it back.
+func updateCredential(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service string, mutate func(*models.Credential)) (*models.PasswordEntry, error) {
+	scope, key, role, err := ResolveScope(ctx, db, sm, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !vault.CanWrite(role) {
+		return nil, vault.ErrForbidden
+	}
+
+	entry, cred, err := vault.RetrieveCredential(db, scope, service, key)
+	if err != nil {
+		return nil, err
+	}
+
+	mutate(&cred)
+	if err := vault.UpsertCredential(db, scope, entry.Service, cred, key); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"passportier-bot/internal/models"
 	"passportier-bot/internal/security"
 	"passportier-bot/internal/vault"
 
@@ -13,30 +14,63 @@ import (
 	"gorm.io/gorm"
 )
 
-// SavePassword encrypts and saves credential to database.
-func SavePassword(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service, data string) error {
-	userKey, err := sm.GetSession(ctx, userID)
+// SavePassword encrypts and saves credential to the active vault.
+// Returns vault.ErrForbidden when the user may only read the active team vault.
+func SavePassword(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service string, cred models.Credential) error {
+	scope, key, role, err := ResolveScope(ctx, db, sm, userID)
 	if err != nil {
-		return fmt.Errorf("session not found")
+		return err
+	}
+	if !vault.CanWrite(role) {
+		return vault.ErrForbidden
+	}
+
+	return vault.UpsertCredential(db, scope, service, cred, key)
+}
+
+// GetPassword retrieves and decrypts credential from the active vault.
+// Returns the matched entry alongside the credential.
+func GetPassword(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service string) (*models.PasswordEntry, models.Credential, error) {
+	scope, key, _, err := ResolveScope(ctx, db, sm, userID)
+	if err != nil {
+		return nil, models.Credential{}, err
 	}
 
-	return vault.UpsertCredential(db, userID, service, data, userKey)
+	return vault.RetrieveCredential(db, scope, service, key)
 }
 
-// GetPassword retrieves and decrypts credential from database.
-func GetPassword(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service string) (string, error) {
-	userKey, err := sm.GetSession(ctx, userID)
+// SetServicePassword replaces the password of an entry, keeping its other fields.
+// The entry is created in the active vault if the service does not exist yet.
+func SetServicePassword(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service, password string) error {
+	scope, key, role, err := ResolveScope(ctx, db, sm, userID)
 	if err != nil {
-		return "", fmt.Errorf("session not found")
+		return err
+	}
+	if !vault.CanWrite(role) {
+		return vault.ErrForbidden
+	}
+
+	var cred models.Credential
+	if entry, err := vault.GetEntry(db, scope, service); err == nil {
+		if cred, err = vault.DecryptCredential(entry, key); err != nil {
+			return err
+		}
 	}
 
-	return vault.RetrieveCredential(db, userID, service, userKey)
+	cred.Password = password
+	return vault.UpsertCredential(db, scope, service, cred, key)
 }
 
 // ScheduleCountdown shows real-time countdown from 30 to 0 seconds.
 // Updates message every 5 seconds to show remaining time.
 func ScheduleCountdown(b *telebot.Bot, msg *telebot.Message, originalText string) {
-	go func(m *telebot.Message, text string) {
+	ScheduleLiveCountdown(b, msg, func() string { return originalText })
+}
+
+// ScheduleLiveCountdown is ScheduleCountdown with the message body re-rendered
+// on every update, so time-dependent content (e.g. TOTP codes) stays current.
+func ScheduleLiveCountdown(b *telebot.Bot, msg *telebot.Message, render func() string) {
+	go func(m *telebot.Message) {
 		remaining := 30
 
 		for remaining > 0 {
@@ -44,7 +78,7 @@ func ScheduleCountdown(b *telebot.Bot, msg *telebot.Message, originalText string
 			remaining -= 5
 
 			if remaining > 0 {
-				countdown := fmt.Sprintf("%s\n\n⏱ _Yashirilishiga %d soniya qoldi..._", text, remaining)
+				countdown := fmt.Sprintf("%s\n\n⏱ _Yashirilishiga %d soniya qoldi..._", render(), remaining)
 				if _, err := b.Edit(m, countdown, telebot.ModeMarkdown); err != nil {
 					log.Printf("Countdown edit error: %v", err)
 					return
@@ -57,5 +91,5 @@ func ScheduleCountdown(b *telebot.Bot, msg *telebot.Message, originalText string
 		if _, err := b.Edit(m, expiredText, telebot.ModeMarkdown); err != nil {
 			log.Printf("Expiration edit error: %v", err)
 		}
-	}(msg, originalText)
+	}(msg)
 }
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"sync"
+	"time"
+)
+
+const setupConfirmWindow = 5 * time.Minute // Time allowed to repeat the new passphrase
+
+// Predefined first-run setup errors
+var (
+	ErrConfirmPassphrase  = errors.New("passphrase confirmation required")
+	ErrPassphraseMismatch = errors.New("passphrase confirmation mismatch")
+	// ErrNoRecoveryCodes means the vault was created and the session opened,
+	// but recovery codes could not be issued and must be generated later.
+	ErrNoRecoveryCodes = errors.New("recovery codes were not issued")
+)
+
+// pendingSetup holds a digest of the first passphrase entry (RAM only).
+type pendingSetup struct {
+	digest  [sha256.Size]byte
+	expires time.Time
+}
+
+var (
+	pendingSetups = make(map[int64]pendingSetup)
+	setupMu       sync.Mutex
+)
+
+// confirmPassphrase implements the two-step master passphrase setup.
+// The first call records the passphrase and returns ErrConfirmPassphrase;
+// a matching second call within the window returns nil.
+func confirmPassphrase(userID int64, passphrase string) error {
+	setupMu.Lock()
+	defer setupMu.Unlock()
+
+	digest := sha256.Sum256([]byte(passphrase))
+	pending, exists := pendingSetups[userID]
+
+	if !exists || time.Now().After(pending.expires) {
+		pendingSetups[userID] = pendingSetup{digest: digest, expires: time.Now().Add(setupConfirmWindow)}
+		return ErrConfirmPassphrase
+	}
+
+	delete(pendingSetups, userID)
+	if subtle.ConstantTimeCompare(digest[:], pending.digest[:]) != 1 {
+		return ErrPassphraseMismatch
+	}
+
+	return nil
+}
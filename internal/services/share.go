@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
+)
+
+// defaultShareURL is the share page used when WEBAPP_SHARE_URL is not set.
+const defaultShareURL = "https://bot.sanakulov.uz/share.html"
+
+// ShareBaseURL returns the share page that /share links point to.
+func ShareBaseURL() string {
+	if base := os.Getenv("WEBAPP_SHARE_URL"); base != "" {
+		return base
+	}
+	return defaultShareURL
+}
+
+// ShareLink is a created one-time link.
+type ShareLink struct {
+	Service   string
+	URL       string // Share page URL; the key is in the fragment, which browsers never send
+	ExpiresAt time.Time
+	MaxViews  int
+}
+
+// ShareCredential creates a self-destructing link for the entry matching service.
+func ShareCredential(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, service string, ttl time.Duration, maxViews int) (*ShareLink, error) {
+	entry, cred, err := GetPassword(ctx, db, sm, userID, service)
+	if err != nil {
+		return nil, err
+	}
+
+	id, key, expiresAt, err := vault.CreateShare(db, userID, entry.Service, cred, ttl, maxViews)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(ShareBaseURL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBAPP_SHARE_URL: %w", err)
+	}
+	u.Fragment = id + "." + key
+
+	return &ShareLink{Service: entry.Service, URL: u.String(), ExpiresAt: expiresAt, MaxViews: maxViews}, nil
+}
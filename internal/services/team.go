@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
+)
+
+// ResolveScope returns the scope the user is working in, the key for it and
+// the user's role: the active team vault with its vault key, or the personal
+// vault with the session data key (role owner).
+func ResolveScope(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64) (vault.Scope, string, string, error) {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return vault.Scope{}, "", "", fmt.Errorf("session not found")
+	}
+
+	active, _, err := vault.ActiveVault(db, userID)
+	if err != nil {
+		return vault.Scope{}, "", "", err
+	}
+	if active == nil {
+		return vault.Personal(userID), userKey, models.RoleOwner, nil
+	}
+	return SharedScope(ctx, db, sm, userID, active.ID)
+}
+
+// SharedScope opens a specific team vault for the user.
+func SharedScope(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, vaultID uint) (vault.Scope, string, string, error) {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return vault.Scope{}, "", "", fmt.Errorf("session not found")
+	}
+
+	vaultKey, role, err := vault.VaultKey(db, vaultID, userID, userKey)
+	if err != nil {
+		return vault.Scope{}, "", "", err
+	}
+	return vault.Shared(vaultID), vaultKey, role, nil
+}
+
+// CreateTeamVault creates a team vault owned by the user.
+func CreateTeamVault(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, name string) (*models.Vault, error) {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found")
+	}
+	return vault.CreateTeamVault(db, userID, userKey, name)
+}
+
+// InviteMember adds a user to the owner's active team vault.
+func InviteMember(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, vaultID uint, inviteeID int64, role string) error {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("session not found")
+	}
+	return vault.AddMember(db, vaultID, userID, userKey, inviteeID, role)
+}
+
+// RemoveMember revokes a member's access and rotates the vault key.
+func RemoveMember(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, vaultID uint, memberID int64) error {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("session not found")
+	}
+	return vault.RemoveMember(db, vaultID, userID, userKey, memberID)
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/transfer"
+	"passportier-bot/internal/vault"
+
+	"gorm.io/gorm"
+)
+
+const importWindow = 5 * time.Minute // Time allowed between /import, the file and confirmation
+
+// pendingImport tracks an import in progress (RAM only).
+// plan is nil while the bot waits for the file. Every pending import is
+// dropped by a timer when its window ends, so an abandoned plan does not keep
+// decrypted credentials in memory.
+type pendingImport struct {
+	plan    *transfer.Plan
+	expires time.Time
+}
+
+var (
+	pendingImports = make(map[int64]pendingImport)
+	importMu       sync.Mutex
+)
+
+// ExportVault decrypts every entry and returns a passphrase-protected export file.
+// Entries that fail to decrypt are not exported and are counted in failed.
+func ExportVault(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, passphrase string) (data []byte, exported, failed int, err error) {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("session not found")
+	}
+
+	entries, err := vault.ListEntries(db, vault.Personal(userID))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	records := make([]transfer.Record, 0, len(entries))
+	for i := range entries {
+		cred, err := vault.DecryptCredential(&entries[i], userKey)
+		if err != nil {
+			failed++
+			continue
+		}
+		records = append(records, transfer.Record{Service: entries[i].Service, Credential: cred})
+	}
+
+	data, err = transfer.Encrypt(records, passphrase)
+	return data, len(records), failed, err
+}
+
+// PlanImport parses an import file and compares it with the vault.
+// Nothing is written; the plan is kept until ApplyImport or expiry.
+func PlanImport(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64, data []byte, passphrase string) (*transfer.Plan, error) {
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found")
+	}
+
+	format, records, err := transfer.Parse(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := vault.ListEntries(db, vault.Personal(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]transfer.Existing, len(entries))
+	for i := range entries {
+		cred, err := vault.DecryptCredential(&entries[i], userKey)
+		existing[strings.ToLower(entries[i].Service)] = transfer.Existing{
+			Service:    entries[i].Service,
+			Credential: cred,
+			Decrypted:  err == nil,
+		}
+	}
+
+	plan := transfer.BuildPlan(format, records, existing)
+
+	setPendingImport(userID, plan)
+	return plan, nil
+}
+
+// ApplyImport writes the pending plan's creates and updates in one transaction.
+// Returns the number of entries written.
+func ApplyImport(ctx context.Context, db *gorm.DB, sm *security.SessionManager, userID int64) (int, error) {
+	importMu.Lock()
+	pending, ok := pendingImports[userID]
+	delete(pendingImports, userID)
+	importMu.Unlock()
+
+	if !ok || pending.plan == nil {
+		return 0, fmt.Errorf("no pending import")
+	}
+	defer pending.plan.Clear()
+	if time.Now().After(pending.expires) {
+		return 0, fmt.Errorf("no pending import")
+	}
+
+	userKey, err := sm.GetSession(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("session not found")
+	}
+
+	// All or nothing: a failing record rolls back the whole import
+	records := pending.plan.Records()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, r := range records {
+			if err := vault.UpsertCredential(tx, vault.Personal(userID), r.Service, r.Credential, userKey); err != nil {
+				return fmt.Errorf("%s: %w", r.Service, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+// AwaitImport marks the user as about to send an import file.
+func AwaitImport(userID int64) {
+	setPendingImport(userID, nil)
+}
+
+// setPendingImport replaces the user's pending import and schedules its expiry.
+func setPendingImport(userID int64, plan *transfer.Plan) {
+	expires := time.Now().Add(importWindow)
+
+	importMu.Lock()
+	if old, ok := pendingImports[userID]; ok && old.plan != nil && old.plan != plan {
+		old.plan.Clear()
+	}
+	pendingImports[userID] = pendingImport{plan: plan, expires: expires}
+	importMu.Unlock()
+
+	time.AfterFunc(importWindow, func() { expireImport(userID, expires) })
+}
+
+// expireImport drops the user's pending import if it is the one that expires
+// at the given time, not a newer one started since.
+func expireImport(userID int64, expires time.Time) {
+	importMu.Lock()
+	defer importMu.Unlock()
+	pending, ok := pendingImports[userID]
+	if !ok || !pending.expires.Equal(expires) {
+		return
+	}
+	if pending.plan != nil {
+		pending.plan.Clear()
+	}
+	delete(pendingImports, userID)
+}
+
+// IsAwaitingImport reports whether /import was issued and no file has arrived yet.
+func IsAwaitingImport(userID int64) bool {
+	importMu.Lock()
+	defer importMu.Unlock()
+	pending, ok := pendingImports[userID]
+	return ok && pending.plan == nil && time.Now().Before(pending.expires)
+}
+
+// CancelImport drops any pending import for the user.
+func CancelImport(userID int64) {
+	importMu.Lock()
+	defer importMu.Unlock()
+	if pending, ok := pendingImports[userID]; ok && pending.plan != nil {
+		pending.plan.Clear()
+	}
+	delete(pendingImports, userID)
+}
@@ -3,7 +3,6 @@ package storage
 import (
 	"fmt"
 	"os"
-	"passportier-bot/internal/models"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -19,9 +18,6 @@ func InitDB() *gorm.DB {
 		panic("Bazaga ulanib bo'lmadi!")
 	}
 
-	// Jadvalni avtomatik yaratish
-	if err := db.AutoMigrate(&models.PasswordEntry{}); err != nil {
-		panic("Migratsiya xatosi: " + err.Error())
-	}
+	// Sxema migratsiyalari cmd/main.go da MigrateUp orqali bajariladi
 	return db
 }
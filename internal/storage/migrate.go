@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"passportier-bot/migrations"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockID is the Postgres advisory lock key held while migrating, so
+// processes started together (e.g. several replicas) do not apply the same
+// migration twice. The value is arbitrary but must never change.
+const migrationLockID int64 = 0x70617373_6d696772 // "passmigr"
+
+// Migration is a versioned schema change loaded from the embedded SQL files.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState reports whether a migration has been applied.
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations bookkeeping table.
+type schemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// LoadMigrations reads NNN_name.up.sql / NNN_name.down.sql pairs, sorted by version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		base := strings.TrimSuffix(file, ".sql")
+		direction := "up"
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			base = strings.TrimSuffix(base, ".up")
+		case strings.HasSuffix(base, ".down"):
+			base, direction = strings.TrimSuffix(base, ".down"), "down"
+		default:
+			return nil, fmt.Errorf("migration %s: missing .up/.down suffix", file)
+		}
+
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version", file)
+		}
+
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s: missing up file", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// MigrateUp applies all pending migrations in order, each in its own transaction,
+// while holding the migration lock. Returns the migrations that were applied.
+func MigrateUp(db *gorm.DB) (done []Migration, err error) {
+	err = withMigrationLock(db, func(conn *gorm.DB) error {
+		done, err = migrateUp(conn)
+		return err
+	})
+	return done, err
+}
+
+func migrateUp(db *gorm.DB) ([]Migration, error) {
+	all, applied, err := loadState(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range all {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the latest applied migrations, newest first, while
+// holding the migration lock. Returns the migrations that were reverted.
+func MigrateDown(db *gorm.DB, steps int) (done []Migration, err error) {
+	err = withMigrationLock(db, func(conn *gorm.DB) error {
+		done, err = migrateDown(conn, steps)
+		return err
+	})
+	return done, err
+}
+
+func migrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	all, applied, err := loadState(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+		m := all[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == "" {
+			return done, fmt.Errorf("migration %03d_%s: no down file", m.Version, m.Name)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrationStatus lists every known migration with its applied time, if any.
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	all, applied, err := loadState(db)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(all))
+	for _, m := range all {
+		state := MigrationState{Migration: m}
+		if row, ok := applied[m.Version]; ok {
+			at := row.AppliedAt
+			state.AppliedAt = &at
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// withMigrationLock runs fn on a single pooled connection holding the
+// session-level advisory lock; the lock must be released on the same
+// connection that took it.
+func withMigrationLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+			return fmt.Errorf("migration lock: %w", err)
+		}
+		defer func() {
+			if err := conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID).Error; err != nil {
+				log.Printf("[MIGRATE] Failed to release migration lock: %v", err)
+			}
+		}()
+		return fn(conn)
+	})
+}
+
+// loadState ensures the bookkeeping table exists and returns all embedded
+// migrations together with the applied ones keyed by version.
+func loadState(db *gorm.DB) ([]Migration, map[int64]schemaMigration, error) {
+	all, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	applied := make(map[int64]schemaMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+	return all, applied, nil
+}
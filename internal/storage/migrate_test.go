@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"passportier-bot/migrations"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_second.up.sql":   {Data: []byte("UP 2")},
+		"001_first.up.sql":    {Data: []byte("UP 1")},
+		"001_first.down.sql":  {Data: []byte("DOWN 1")},
+		"010_tenth.up.sql":    {Data: []byte("UP 10")},
+		"010_tenth.down.sql":  {Data: []byte("DOWN 10")},
+		"README.md":           {Data: []byte("ignored")},
+		"002_second.down.sql": {Data: []byte("DOWN 2")},
+	}
+
+	got, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	want := []Migration{
+		{Version: 1, Name: "first", Up: "UP 1", Down: "DOWN 1"},
+		{Version: 2, Name: "second", Up: "UP 2", Down: "DOWN 2"},
+		{Version: 10, Name: "tenth", Up: "UP 10", Down: "DOWN 10"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d migrations, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("migration %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing up":      {"001_first.down.sql": {Data: []byte("DOWN")}},
+		"no direction":    {"001_first.sql": {Data: []byte("UP")}},
+		"invalid version": {"one_first.up.sql": {Data: []byte("UP")}},
+	}
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadMigrations(fsys); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+// TestMigrateRoundTrip runs the embedded migrations against a real Postgres.
+// TEST_DATABASE_URL must name a throwaway database: the test reverts every
+// migration in it.
+func TestMigrateRoundTrip(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	all, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	if _, err := MigrateDown(db, len(all)); err != nil {
+		t.Fatalf("initial MigrateDown: %v", err)
+	}
+
+	applied := func() int {
+		t.Helper()
+		states, err := MigrationStatus(db)
+		if err != nil {
+			t.Fatalf("MigrationStatus: %v", err)
+		}
+		n := 0
+		for _, s := range states {
+			if s.AppliedAt != nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	// Concurrent runs apply every migration exactly once between them
+	var wg sync.WaitGroup
+	counts := make([]int, 2)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			done, err := MigrateUp(db)
+			if err != nil {
+				t.Errorf("MigrateUp: %v", err)
+			}
+			counts[i] = len(done)
+		}(i)
+	}
+	wg.Wait()
+	if counts[0]+counts[1] != len(all) {
+		t.Fatalf("applied %d + %d migrations, want %d in total", counts[0], counts[1], len(all))
+	}
+	if n := applied(); n != len(all) {
+		t.Fatalf("status: %d applied, want %d", n, len(all))
+	}
+
+	reverted, err := MigrateDown(db, 1)
+	if err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != all[len(all)-1].Version {
+		t.Fatalf("reverted %+v, want only the latest migration", reverted)
+	}
+	if n := applied(); n != len(all)-1 {
+		t.Fatalf("status after down: %d applied, want %d", n, len(all)-1)
+	}
+
+	if _, err := MigrateDown(db, len(all)); err != nil {
+		t.Fatalf("MigrateDown all: %v", err)
+	}
+	if n := applied(); n != 0 {
+		t.Fatalf("status after full down: %d applied, want 0", n)
+	}
+
+	done, err := MigrateUp(db)
+	if err != nil {
+		t.Fatalf("MigrateUp again: %v", err)
+	}
+	if len(done) != len(all) {
+		t.Fatalf("reapplied %d migrations, want %d", len(done), len(all))
+	}
+}
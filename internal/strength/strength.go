@@ -0,0 +1,243 @@
+// Package strength estimates password strength in the spirit of zxcvbn:
+// the password is split into recognizable patterns (common passwords,
+// keyboard runs, sequences, repeats, years) plus brute-forced characters,
+// and the number of guesses an attacker needs is turned into a 0-4 score.
+package strength
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Score thresholds in guesses (log10), as used by zxcvbn.
+const (
+	scoreGuesses1 = 3  // < 10^3: too guessable
+	scoreGuesses2 = 6  // < 10^6: very guessable
+	scoreGuesses3 = 8  // < 10^8: somewhat guessable
+	scoreGuesses4 = 10 // < 10^10: safely unguessable
+)
+
+// minRunLength is the shortest sequence, repeat or keyboard run recognized.
+const minRunLength = 3
+
+// keyboardRows are the rows scanned for keyboard-walk patterns.
+var keyboardRows = []string{"qwertyuiop", "asdfghjkl", "zxcvbnm", "1234567890"}
+
+//go:embed common.txt
+var commonList string
+
+// commonRank maps a common password or word to its popularity rank (1 = most common).
+var commonRank = func() map[string]int {
+	ranks := make(map[string]int)
+	for i, w := range strings.Fields(commonList) {
+		ranks[w] = i + 1
+	}
+	return ranks
+}()
+
+// Result is a strength estimate.
+type Result struct {
+	Score    int     // 0 (weakest) to 4 (strongest)
+	Guesses  float64 // Estimated guesses needed
+	Feedback string  // Main weakness, empty when none stands out
+}
+
+// Estimate scores password.
+func Estimate(password string) Result {
+	if password == "" {
+		return Result{Score: 0, Guesses: 1, Feedback: "empty"}
+	}
+
+	runes := []rune(password)
+	lower := []rune(strings.ToLower(password))
+
+	// log10 of the guesses, summed over segments, to avoid overflow
+	logGuesses := 0.0
+	segments := 0
+	feedback := ""
+
+	bruteforce := false // Consecutive unmatched characters form one segment
+	for i := 0; i < len(runes); {
+		n, g, why := longestPattern(lower, runes, i)
+		if n == 0 {
+			logGuesses += math.Log10(float64(charCardinality(runes[i])))
+			if !bruteforce {
+				segments++
+				bruteforce = true
+			}
+			i++
+			continue
+		}
+
+		if feedback == "" {
+			feedback = why
+		}
+		logGuesses += math.Log10(g)
+		segments++
+		bruteforce = false
+		i += n
+	}
+
+	// The attacker also has to guess how the segments are combined
+	logGuesses += math.Log10(factorial(segments))
+
+	guesses := math.Pow(10, logGuesses)
+	score := 4
+	switch {
+	case logGuesses < scoreGuesses1:
+		score = 0
+	case logGuesses < scoreGuesses2:
+		score = 1
+	case logGuesses < scoreGuesses3:
+		score = 2
+	case logGuesses < scoreGuesses4:
+		score = 3
+	}
+
+	if feedback == "" && len(runes) < 8 {
+		feedback = "short"
+	}
+	return Result{Score: score, Guesses: guesses, Feedback: feedback}
+}
+
+// longestPattern returns the length, guesses and kind of the longest pattern
+// starting at i, or length 0 when none applies.
+func longestPattern(lower, orig []rune, i int) (int, float64, string) {
+	bestLen, bestGuesses, bestKind := 0, 0.0, ""
+	consider := func(n int, g float64, kind string) {
+		if n > bestLen {
+			bestLen, bestGuesses, bestKind = n, g, kind
+		}
+	}
+
+	// Common passwords and words, with leet substitutions undone
+	for j := len(lower); j > i+minRunLength-1; j-- {
+		word := string(lower[i:j])
+		rank, ok := commonRank[word]
+		leet := false
+		if !ok {
+			rank, ok = commonRank[unleet(word)]
+			leet = ok
+		}
+		if ok {
+			g := float64(rank)
+			if hasUpper(orig[i:j]) {
+				g *= 2
+			}
+			if leet {
+				g *= 2
+			}
+			consider(j-i, math.Max(g, 10), "common")
+			break
+		}
+	}
+
+	if n := repeatLength(lower, i); n >= minRunLength {
+		consider(n, float64(charCardinality(orig[i])*n), "repeat")
+	}
+	if n := sequenceLength(lower, i); n >= minRunLength {
+		consider(n, float64(26*n), "sequence")
+	}
+	if n := keyboardLength(lower, i); n >= minRunLength+1 {
+		consider(n, float64(len(keyboardRows)*10*n), "keyboard")
+	}
+	if isYear(lower, i) {
+		consider(4, 130, "year")
+	}
+
+	return bestLen, bestGuesses, bestKind
+}
+
+// repeatLength counts identical characters starting at i.
+func repeatLength(s []rune, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// sequenceLength counts characters starting at i that step by +1 or -1 (abc, 987).
+func sequenceLength(s []rune, i int) int {
+	if i+1 >= len(s) {
+		return 1
+	}
+	step := s[i+1] - s[i]
+	if step != 1 && step != -1 {
+		return 1
+	}
+	n := 2
+	for i+n < len(s) && s[i+n]-s[i+n-1] == step {
+		n++
+	}
+	return n
+}
+
+// keyboardLength counts characters starting at i that walk along a keyboard row.
+func keyboardLength(s []rune, i int) int {
+	best := 1
+	for _, row := range keyboardRows {
+		r := []rune(row)
+		for k := range r {
+			if r[k] != s[i] {
+				continue
+			}
+			n := 1
+			for i+n < len(s) && k+n < len(r) && s[i+n] == r[k+n] {
+				n++
+			}
+			if n > best {
+				best = n
+			}
+		}
+	}
+	return best
+}
+
+// isYear reports whether a plausible year (1900-2039) starts at i.
+func isYear(s []rune, i int) bool {
+	if i+4 > len(s) {
+		return false
+	}
+	y := string(s[i : i+4])
+	return (strings.HasPrefix(y, "19") || strings.HasPrefix(y, "20") && y[2] <= '3') &&
+		unicode.IsDigit(rune(y[2])) && unicode.IsDigit(rune(y[3]))
+}
+
+// unleet reverses common character substitutions (p@ssw0rd -> password).
+func unleet(s string) string {
+	return strings.NewReplacer("@", "a", "4", "a", "3", "e", "1", "i", "!", "i", "0", "o", "$", "s", "5", "s", "7", "t").Replace(s)
+}
+
+// charCardinality is the size of the character class an attacker must brute-force.
+func charCardinality(r rune) int {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLower(r) || unicode.IsUpper(r):
+		return 26
+	case r < unicode.MaxASCII:
+		return 33
+	default:
+		return 100
+	}
+}
+
+func hasUpper(rs []rune) bool {
+	for _, r := range rs {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
@@ -0,0 +1,116 @@
+package totp
+
+import (
+	"encoding/base32"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 Appendix B seeds: the ASCII digits repeated to the hash size.
+var rfcSeeds = map[string][]byte{
+	"SHA1":   []byte("12345678901234567890"),
+	"SHA256": []byte("12345678901234567890123456789012"),
+	"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+}
+
+func TestGenerateRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want map[string]string
+	}{
+		{59, map[string]string{"SHA1": "94287082", "SHA256": "46119246", "SHA512": "90693936"}},
+		{1111111109, map[string]string{"SHA1": "07081804", "SHA256": "68084774", "SHA512": "25091201"}},
+		{1111111111, map[string]string{"SHA1": "14050471", "SHA256": "67062674", "SHA512": "99943326"}},
+		{1234567890, map[string]string{"SHA1": "89005924", "SHA256": "91819424", "SHA512": "93441116"}},
+		{2000000000, map[string]string{"SHA1": "69279037", "SHA256": "90698825", "SHA512": "38618901"}},
+		{20000000000, map[string]string{"SHA1": "65353130", "SHA256": "77737706", "SHA512": "47863826"}},
+	}
+
+	for _, tt := range tests {
+		for alg, want := range tt.want {
+			cfg := Config{Secret: rfcSeeds[alg], Algorithm: alg, Digits: 8, Period: 30}
+			if got := Generate(cfg, time.Unix(tt.unix, 0)).Code; got != want {
+				t.Errorf("%s at %d = %s, want %s", alg, tt.unix, got, want)
+			}
+		}
+	}
+}
+
+func TestGenerateRemaining(t *testing.T) {
+	cfg := Config{Secret: rfcSeeds["SHA1"], Algorithm: "SHA1", Digits: 6, Period: 30}
+
+	code := Generate(cfg, time.Unix(59, 0))
+	if code.Remaining != 1 || code.Period != 30 {
+		t.Fatalf("Remaining = %d, Period = %d, want 1, 30", code.Remaining, code.Period)
+	}
+	// Six digits are the low end of the eight-digit code
+	if code.Code != "287082" {
+		t.Fatalf("Code = %s, want 287082", code.Code)
+	}
+}
+
+func TestParse(t *testing.T) {
+	sha1Secret := base32.StdEncoding.EncodeToString(rfcSeeds["SHA1"])
+	sha256Secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(rfcSeeds["SHA256"])
+
+	tests := []struct {
+		name    string
+		value   string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name:  "bare secret",
+			value: sha1Secret,
+			want:  Config{Secret: rfcSeeds["SHA1"], Algorithm: "SHA1", Digits: 6, Period: 30},
+		},
+		{
+			name:  "lowercase with spaces",
+			value: " " + strings.ToLower(sha1Secret[:8]+" "+sha1Secret[8:]) + " ",
+			want:  Config{Secret: rfcSeeds["SHA1"], Algorithm: "SHA1", Digits: 6, Period: 30},
+		},
+		{
+			name:  "uri defaults",
+			value: "otpauth://totp/ACME:ali@example.com?secret=" + sha1Secret + "&issuer=ACME",
+			want:  Config{Secret: rfcSeeds["SHA1"], Algorithm: "SHA1", Digits: 6, Period: 30},
+		},
+		{
+			name:  "uri parameters",
+			value: "otpauth://totp/ACME:ali?secret=" + sha256Secret + "&algorithm=sha256&digits=8&period=60",
+			want:  Config{Secret: rfcSeeds["SHA256"], Algorithm: "SHA256", Digits: 8, Period: 60},
+		},
+		{
+			name:  "uppercase scheme",
+			value: "OTPAUTH://TOTP/ACME?secret=" + sha1Secret,
+			want:  Config{Secret: rfcSeeds["SHA1"], Algorithm: "SHA1", Digits: 6, Period: 30},
+		},
+		{name: "hotp uri", value: "otpauth://hotp/ACME?secret=" + sha1Secret + "&counter=1", wantErr: true},
+		{name: "uri without secret", value: "otpauth://totp/ACME?issuer=ACME", wantErr: true},
+		{name: "unknown algorithm", value: "otpauth://totp/ACME?secret=" + sha1Secret + "&algorithm=MD5", wantErr: true},
+		{name: "too many digits", value: "otpauth://totp/ACME?secret=" + sha1Secret + "&digits=10", wantErr: true},
+		{name: "zero period", value: "otpauth://totp/ACME?secret=" + sha1Secret + "&period=0", wantErr: true},
+		{name: "not base32", value: "not-a-secret!", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSecret) {
+					t.Fatalf("err = %v, want ErrInvalidSecret", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package transfer
+
+import (
+	"encoding/json"
+
+	"passportier-bot/internal/models"
+)
+
+// Bitwarden item types that carry credentials we can map.
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+)
+
+// bitwardenExport mirrors the unencrypted Bitwarden JSON export.
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Items     []struct {
+		Type   int    `json:"type"`
+		Name   string `json:"name"`
+		Notes  string `json:"notes"`
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+		Login *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			TOTP     string `json:"totp"`
+			URIs     []struct {
+				URI string `json:"uri"`
+			} `json:"uris"`
+		} `json:"login"`
+	} `json:"items"`
+}
+
+// parseBitwarden maps login and secure-note items; other item types are skipped.
+func parseBitwarden(data []byte) ([]Record, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	if export.Encrypted {
+		return nil, ErrEncryptedBitwarden
+	}
+
+	var records []Record
+	for _, item := range export.Items {
+		if item.Type != bitwardenLogin && item.Type != bitwardenSecureNote {
+			continue
+		}
+
+		cred := models.Credential{Notes: item.Notes}
+		for _, f := range item.Fields {
+			cred.Fields = append(cred.Fields, models.CustomField{Name: f.Name, Value: f.Value})
+		}
+		if item.Login != nil {
+			cred.Login = item.Login.Username
+			cred.Password = item.Login.Password
+			cred.TOTP = item.Login.TOTP
+			for _, u := range item.Login.URIs {
+				if u.URI != "" {
+					cred.URLs = append(cred.URLs, u.URI)
+				}
+			}
+		}
+
+		if r, ok := newRecord(item.Name, cred); ok {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
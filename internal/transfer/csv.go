@@ -0,0 +1,105 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+
+	"passportier-bot/internal/models"
+)
+
+// csvColumns maps normalized header names (1Password, Chrome and similar
+// exports) onto credential fields.
+var csvColumns = map[string]string{
+	"title":          "service",
+	"name":           "service",
+	"url":            "url",
+	"website":        "url",
+	"login_uri":      "url",
+	"username":       "login",
+	"login_username": "login",
+	"password":       "password",
+	"login_password": "password",
+	"notes":          "notes",
+	"note":           "notes",
+	"notesplain":     "notes",
+	"otpauth":        "totp",
+	"totp":           "totp",
+	"login_totp":     "totp",
+}
+
+// detectCSV recognizes 1Password ("Title" column) and Chrome ("name,url") headers.
+func detectCSV(data []byte) (string, error) {
+	header, err := csv.NewReader(bytes.NewReader(data)).Read()
+	if err != nil {
+		return "", ErrUnknownFormat
+	}
+
+	cols := make(map[string]bool, len(header))
+	for _, h := range header {
+		cols[normalizeHeader(h)] = true
+	}
+
+	switch {
+	case cols["title"] && cols["password"]:
+		return Format1Password, nil
+	case cols["name"] && cols["url"] && cols["password"]:
+		return FormatChrome, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// parseCSV reads a header-driven CSV export.
+func parseCSV(data []byte) ([]Record, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, nil
+	}
+
+	fields := make([]string, len(rows[0]))
+	for i, h := range rows[0] {
+		fields[i] = csvColumns[normalizeHeader(h)]
+	}
+
+	var records []Record
+	for _, row := range rows[1:] {
+		var service string
+		var cred models.Credential
+
+		for i, value := range row {
+			if i >= len(fields) || value == "" {
+				continue
+			}
+			switch fields[i] {
+			case "service":
+				service = value
+			case "url":
+				cred.URLs = append(cred.URLs, value)
+			case "login":
+				cred.Login = value
+			case "password":
+				cred.Password = value
+			case "notes":
+				cred.Notes = value
+			case "totp":
+				cred.TOTP = value
+			}
+		}
+
+		if r, ok := newRecord(service, cred); ok {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+func normalizeHeader(h string) string {
+	h = strings.TrimPrefix(h, "\xef\xbb\xbf")
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(h)), " ", "_")
+}
@@ -0,0 +1,107 @@
+package transfer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/models"
+)
+
+const (
+	exportFormat  = "passportier-export"
+	exportVersion = 1
+)
+
+// exportFile is the on-disk PassPortier backup: the entry list is encrypted
+// with AES-256-GCM under an Argon2id key derived from the export passphrase.
+type exportFile struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	KDF       string    `json:"kdf"`
+	Salt      string    `json:"salt"`
+	Data      string    `json:"data"` // base64(Nonce[12] + Ciphertext)
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// exportEntry is one plaintext entry inside the encrypted payload.
+type exportEntry struct {
+	Service    string            `json:"service"`
+	Credential models.Credential `json:"credential"`
+}
+
+// Encrypt produces a passphrase-protected PassPortier export of the records.
+func Encrypt(records []Record, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+
+	entries := make([]exportEntry, 0, len(records))
+	for _, r := range records {
+		entries = append(entries, exportEntry{Service: r.Service, Credential: r.Credential})
+	}
+
+	plain, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := crypto.Encrypt(plain, crypto.DeriveKey(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(exportFile{
+		Format:    exportFormat,
+		Version:   exportVersion,
+		KDF:       "argon2id",
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+		Data:      base64.StdEncoding.EncodeToString(sealed),
+		CreatedAt: time.Now().UTC(),
+	}, "", "  ")
+}
+
+// Decrypt opens a PassPortier export. A wrong passphrase yields crypto.ErrInvalidPassword.
+func Decrypt(data []byte, passphrase string) ([]Record, error) {
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+
+	var file exportFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Format != exportFormat {
+		return nil, ErrUnknownFormat
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(file.Salt)
+	if err != nil {
+		return nil, crypto.ErrDataTooShort
+	}
+	sealed, err := base64.StdEncoding.DecodeString(file.Data)
+	if err != nil {
+		return nil, crypto.ErrDataTooShort
+	}
+
+	plain, err := crypto.Decrypt(sealed, crypto.DeriveKey(passphrase, salt))
+	if err != nil {
+		return nil, crypto.ErrInvalidPassword
+	}
+
+	var entries []exportEntry
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(entries))
+	for _, e := range entries {
+		if r, ok := newRecord(e.Service, e.Credential); ok {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,89 @@
+package transfer
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"passportier-bot/internal/models"
+)
+
+// keepassRecycleBin is the default name of KeePass's trash group, which is not imported.
+const keepassRecycleBin = "Recycle Bin"
+
+// keepassGroup mirrors a <Group> of the KeePass 2.x XML export.
+type keepassGroup struct {
+	Name    string         `xml:"Name"`
+	Entries []keepassEntry `xml:"Entry"`
+	Groups  []keepassGroup `xml:"Group"`
+}
+
+// keepassEntry holds the String key/value pairs of an <Entry>.
+// History entries are nested separately and are therefore ignored.
+type keepassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+}
+
+// parseKeePass walks all groups (except the recycle bin) and maps standard fields.
+// Non-standard strings become custom fields; KeePassXC's "otp" becomes the TOTP secret.
+func parseKeePass(data []byte) ([]Record, error) {
+	var file struct {
+		Root struct {
+			Groups []keepassGroup `xml:"Group"`
+		} `xml:"Root"`
+	}
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	var walk func(groups []keepassGroup)
+	walk = func(groups []keepassGroup) {
+		for _, g := range groups {
+			if g.Name == keepassRecycleBin {
+				continue
+			}
+			for _, e := range g.Entries {
+				if r, ok := keepassRecord(e); ok {
+					records = append(records, r)
+				}
+			}
+			walk(g.Groups)
+		}
+	}
+	walk(file.Root.Groups)
+
+	return records, nil
+}
+
+func keepassRecord(e keepassEntry) (Record, bool) {
+	var title string
+	var cred models.Credential
+
+	for _, s := range e.Strings {
+		switch strings.ToLower(s.Key) {
+		case "title":
+			title = s.Value
+		case "username":
+			cred.Login = s.Value
+		case "password":
+			cred.Password = s.Value
+		case "url":
+			if s.Value != "" {
+				cred.URLs = append(cred.URLs, s.Value)
+			}
+		case "notes":
+			cred.Notes = s.Value
+		case "otp":
+			cred.TOTP = s.Value
+		default:
+			if s.Value != "" {
+				cred.Fields = append(cred.Fields, models.CustomField{Name: s.Key, Value: s.Value})
+			}
+		}
+	}
+
+	return newRecord(title, cred)
+}
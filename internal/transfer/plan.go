@@ -0,0 +1,98 @@
+package transfer
+
+import (
+	"reflect"
+	"strings"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/totp"
+)
+
+// Conflict reasons reported in a Plan.
+const (
+	ConflictDuplicate   = "duplicate"   // Service appears more than once in the file
+	ConflictUndecrypted = "undecrypted" // Existing entry cannot be decrypted with the session key
+	ConflictInvalidTOTP = "invalid_totp"
+)
+
+// Existing describes a vault entry the import is compared against.
+// Decrypted is false when the entry failed to decrypt.
+type Existing struct {
+	Service    string
+	Credential models.Credential
+	Decrypted  bool
+}
+
+// Conflict is an imported record that will not be applied.
+type Conflict struct {
+	Service string
+	Reason  string
+}
+
+// Plan is the dry-run summary of an import: nothing is written until it is applied.
+type Plan struct {
+	Format    string
+	Creates   []Record
+	Updates   []Record
+	Conflicts []Conflict
+	Unchanged int
+}
+
+// BuildPlan classifies records against the existing vault, keyed by lower-cased service name.
+func BuildPlan(format string, records []Record, existing map[string]Existing) *Plan {
+	plan := &Plan{Format: format}
+
+	counts := make(map[string]int, len(records))
+	for _, r := range records {
+		counts[strings.ToLower(r.Service)]++
+	}
+
+	reported := make(map[string]bool)
+	for _, r := range records {
+		key := strings.ToLower(r.Service)
+		if counts[key] > 1 {
+			if !reported[key] {
+				plan.Conflicts = append(plan.Conflicts, Conflict{Service: r.Service, Reason: ConflictDuplicate})
+				reported[key] = true
+			}
+			continue
+		}
+
+		if r.Credential.TOTP != "" {
+			if _, err := totp.Parse(r.Credential.TOTP); err != nil {
+				plan.Conflicts = append(plan.Conflicts, Conflict{Service: r.Service, Reason: ConflictInvalidTOTP})
+				continue
+			}
+		}
+
+		cur, ok := existing[key]
+		switch {
+		case !ok:
+			plan.Creates = append(plan.Creates, r)
+		case !cur.Decrypted:
+			plan.Conflicts = append(plan.Conflicts, Conflict{Service: cur.Service, Reason: ConflictUndecrypted})
+		case sameCredential(cur.Credential, r.Credential):
+			plan.Unchanged++
+		default:
+			plan.Updates = append(plan.Updates, Record{Service: cur.Service, Credential: r.Credential})
+		}
+	}
+
+	return plan
+}
+
+// Records returns all records the plan will write.
+func (p *Plan) Records() []Record {
+	return append(append([]Record{}, p.Creates...), p.Updates...)
+}
+
+// Clear drops the plan's records so the decrypted credentials it holds are
+// no longer reachable once the plan has been applied or abandoned.
+func (p *Plan) Clear() {
+	p.Creates, p.Updates, p.Conflicts = nil, nil, nil
+}
+
+func sameCredential(a, b models.Credential) bool {
+	a.Version, b.Version = 0, 0
+	return reflect.DeepEqual(a, b)
+}
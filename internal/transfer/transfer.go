@@ -0,0 +1,115 @@
+// Package transfer handles vault export and import: the encrypted
+// PassPortier backup format and plaintext exports from other password managers.
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+
+	"passportier-bot/internal/models"
+)
+
+// Supported import formats.
+const (
+	FormatPassPortier = "passportier"
+	FormatBitwarden   = "bitwarden"
+	FormatKeePass     = "keepass"
+	Format1Password   = "1password"
+	FormatChrome      = "chrome"
+)
+
+// Predefined import errors
+var (
+	ErrUnknownFormat      = errors.New("unrecognized import format")
+	ErrEncryptedBitwarden = errors.New("encrypted Bitwarden exports are not supported")
+	ErrPassphraseRequired = errors.New("export passphrase required")
+)
+
+// Record is a single credential read from an import file.
+type Record struct {
+	Service    string
+	Credential models.Credential
+}
+
+// Detect sniffs the file content and returns its format.
+func Detect(data []byte) (string, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var probe struct {
+			Format string          `json:"format"`
+			Items  json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return "", ErrUnknownFormat
+		}
+		if probe.Format == exportFormat {
+			return FormatPassPortier, nil
+		}
+		if probe.Items != nil {
+			return FormatBitwarden, nil
+		}
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		if bytes.Contains(trimmed, []byte("<KeePassFile")) {
+			return FormatKeePass, nil
+		}
+	default:
+		return detectCSV(trimmed)
+	}
+
+	return "", ErrUnknownFormat
+}
+
+// Parse reads all records from data. The passphrase is only used
+// for PassPortier exports.
+func Parse(data []byte, passphrase string) (string, []Record, error) {
+	format, err := Detect(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var records []Record
+	switch format {
+	case FormatPassPortier:
+		records, err = Decrypt(data, passphrase)
+	case FormatBitwarden:
+		records, err = parseBitwarden(data)
+	case FormatKeePass:
+		records, err = parseKeePass(data)
+	default:
+		records, err = parseCSV(data)
+	}
+
+	return format, records, err
+}
+
+// serviceFromURL derives a service name from a URL host when a record has no title.
+func serviceFromURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// newRecord builds a record, falling back to the first URL for the service name.
+func newRecord(service string, cred models.Credential) (Record, bool) {
+	service = strings.TrimSpace(service)
+	if service == "" && len(cred.URLs) > 0 {
+		service = serviceFromURL(cred.URLs[0])
+	}
+	if service == "" || cred.IsEmpty() {
+		return Record{}, false
+	}
+	return Record{Service: service, Credential: cred}, true
+}
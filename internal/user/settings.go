@@ -1,10 +1,14 @@
 package user
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"strconv"
 
 	"passportier-bot/internal/models"
+	"passportier-bot/internal/security"
+	"passportier-bot/internal/services"
 
 	"gopkg.in/telebot.v3"
 	"gorm.io/gorm"
@@ -19,12 +23,21 @@ func HandleSettings() telebot.HandlerFunc {
 		btn5Min := menu.Data("⏱ 5 Mins", "autolock", "300")
 		btn1Hour := menu.Data("⏳ 1 Hour", "autolock", "3600")
 
+		btnKeep5 := menu.Data("🕘 Keep 5", "history_keep", "5")
+		btnKeep10 := menu.Data("🕘 Keep 10", "history_keep", "10")
+		btnKeep25 := menu.Data("🕘 Keep 25", "history_keep", "25")
+
+		btnMetaPlain := menu.Data("🏷 Plain folders/tags", "meta_encrypt", "0")
+		btnMetaEnc := menu.Data("🔐 Encrypted folders/tags", "meta_encrypt", "1")
+
 		menu.Inline(
 			menu.Row(btnImmed),
 			menu.Row(btn5Min, btn1Hour),
+			menu.Row(btnKeep5, btnKeep10, btnKeep25),
+			menu.Row(btnMetaPlain, btnMetaEnc),
 		)
 
-		return c.Send("⚙️ *Settings*\n\nChoose Auto-Lock Duration:", menu)
+		return c.Send("⚙️ *Settings*\n\nChoose Auto-Lock Duration, how many past versions to keep per entry and whether folders/tags are stored encrypted:", menu)
 	}
 }
 
@@ -50,6 +63,52 @@ func HandleAutoLockCallback(db *gorm.DB) telebot.HandlerFunc {
 	}
 }
 
+// HandleHistoryRetentionCallback updates how many past versions are kept per entry.
+func HandleHistoryRetentionCallback(db *gorm.DB) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		keep, err := strconv.Atoi(c.Data())
+		if err != nil || keep <= 0 {
+			return c.Respond(&telebot.CallbackResponse{Text: "Invalid option"})
+		}
+
+		userID := c.Sender().ID
+
+		// Update user setting in DB
+		if err := db.Model(&models.User{}).Where("telegram_id = ?", userID).Update("history_retention", keep).Error; err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "Failed to update settings"})
+		}
+
+		msg := fmt.Sprintf("✅ History: last %d versions kept per entry", keep)
+		c.Edit(msg)
+		return c.Respond(&telebot.CallbackResponse{Text: "Settings saved"})
+	}
+}
+
+// HandleMetadataEncryptionCallback switches folders/tags between plaintext and
+// encrypted storage. Existing entries are rewritten, so the session must be open.
+func HandleMetadataEncryptionCallback(db *gorm.DB, sm *security.SessionManager) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		encrypt := c.Data() == "1"
+		userID := c.Sender().ID
+
+		if _, err := sm.GetSession(context.Background(), userID); err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "🔒 Unlock first (/unlock) to change this setting"})
+		}
+
+		if err := services.SetMetadataEncryption(context.Background(), db, sm, userID, encrypt); err != nil {
+			log.Printf("[ERROR] Metadata encryption change failed for User %d: %v", userID, err)
+			return c.Respond(&telebot.CallbackResponse{Text: "❌ Could not change this setting, please try again"})
+		}
+
+		msg := "✅ Folders and tags are stored in plaintext (visible to the server)"
+		if encrypt {
+			msg = "✅ Folders and tags are stored encrypted"
+		}
+		c.Edit(msg)
+		return c.Respond(&telebot.CallbackResponse{Text: "Settings saved"})
+	}
+}
+
 func formatDuration(seconds int64) string {
 	if seconds == 0 {
 		return "Immediately"
@@ -0,0 +1,95 @@
+package vault
+
+import (
+	"encoding/json"
+	"strings"
+
+	"passportier-bot/internal/models"
+)
+
+// Legacy free-text line prefixes written by the old WebApp forms.
+const (
+	legacyLoginPrefix = "Login:"
+	legacyPassPrefix  = "Pass:"
+	legacyNotePrefix  = "Note:"
+)
+
+// EncodeCredential serializes a credential for encryption.
+func EncodeCredential(cred models.Credential) (string, error) {
+	cred.Version = models.CredentialVersion
+	data, err := json.Marshal(cred)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ParseCredential decodes a decrypted payload. Structured JSON records are
+// returned as-is; anything else is treated as a legacy free-text entry.
+func ParseCredential(plain string) models.Credential {
+	trimmed := strings.TrimSpace(plain)
+	if strings.HasPrefix(trimmed, "{") {
+		var cred models.Credential
+		if err := json.Unmarshal([]byte(trimmed), &cred); err == nil && cred.Version >= 1 {
+			return cred
+		}
+	}
+	return parseLegacyCredential(plain)
+}
+
+// parseLegacyCredential maps free text onto the credential schema.
+// "Login:/Pass:/Note:" lines are recognized; otherwise "login password"
+// pairs and single values are guessed, and longer text becomes notes.
+func parseLegacyCredential(text string) models.Credential {
+	var cred models.Credential
+	var notes []string
+	tagged := false
+
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.HasPrefix(line, legacyLoginPrefix):
+			cred.Login = strings.TrimSpace(strings.TrimPrefix(line, legacyLoginPrefix))
+			tagged = true
+		case strings.HasPrefix(line, legacyPassPrefix):
+			cred.Password = strings.TrimSpace(strings.TrimPrefix(line, legacyPassPrefix))
+			tagged = true
+		case strings.HasPrefix(line, legacyNotePrefix):
+			notes = append(notes, strings.TrimSpace(strings.TrimPrefix(line, legacyNotePrefix)))
+			tagged = true
+		case strings.TrimSpace(line) != "":
+			notes = append(notes, strings.TrimSpace(line))
+		}
+	}
+
+	if tagged {
+		cred.Notes = strings.Join(notes, "\n")
+		return cred
+	}
+
+	words := strings.Fields(text)
+	switch len(words) {
+	case 0:
+	case 1:
+		cred.Password = words[0]
+	case 2:
+		cred.Login, cred.Password = words[0], words[1]
+	default:
+		cred.Notes = strings.TrimSpace(text)
+	}
+	return cred
+}
+
+// FormatCredential renders a credential in the legacy "Login:/Pass:/Note:" text form.
+func FormatCredential(cred models.Credential) string {
+	var lines []string
+	if cred.Login != "" {
+		lines = append(lines, legacyLoginPrefix+" "+cred.Login)
+	}
+	if cred.Password != "" {
+		lines = append(lines, legacyPassPrefix+" "+cred.Password)
+	}
+	if cred.Notes != "" {
+		lines = append(lines, legacyNotePrefix+" "+cred.Notes)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // DeleteEntry removes a password entry by service name.
-func DeleteEntry(db *gorm.DB, userID int64, service string) error {
-	return db.Where("user_id = ? AND service = ?", userID, service).
+func DeleteEntry(db *gorm.DB, scope Scope, service string) error {
+	return scope.where(db).Where("service = ?", service).
 		Delete(&models.PasswordEntry{}).Error
 }
@@ -0,0 +1,229 @@
+package vault
+
+import (
+	"errors"
+	"time"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Emergency access waiting period limits, in days.
+const (
+	DefaultEmergencyWait = 7
+	MinEmergencyWait     = 1
+	MaxEmergencyWait     = 30
+)
+
+// Predefined emergency access errors
+var (
+	ErrEmergencyWait     = errors.New("waiting period out of range")
+	ErrEmergencySelf     = errors.New("cannot be your own emergency contact")
+	ErrEmergencyNotFound = errors.New("no such emergency contact")
+	ErrEmergencyPending  = errors.New("emergency access not granted yet")
+)
+
+// EmergencyState is the stage of a contact's access request.
+type EmergencyState int
+
+// Emergency access states.
+const (
+	EmergencyIdle    EmergencyState = iota // No request
+	EmergencyPending                       // Requested, waiting period running
+	EmergencyGranted                       // Waiting period passed without rejection
+)
+
+// EmergencyStatus returns the state of a contact's request at now.
+func EmergencyStatus(c *models.EmergencyContact, now time.Time) EmergencyState {
+	switch {
+	case c.RequestedAt == nil || c.GrantAt == nil:
+		return EmergencyIdle
+	case now.Before(*c.GrantAt):
+		return EmergencyPending
+	default:
+		return EmergencyGranted
+	}
+}
+
+// AddEmergencyContact nominates a trusted contact, sealing the owner's data key
+// to the contact's public key. Nominating an existing contact again updates the
+// waiting period and cancels any pending request.
+func AddEmergencyContact(db *gorm.DB, ownerID int64, userKey string, contactID int64, waitDays int) error {
+	if ownerID == contactID {
+		return ErrEmergencySelf
+	}
+	if waitDays < MinEmergencyWait || waitDays > MaxEmergencyWait {
+		return ErrEmergencyWait
+	}
+
+	pub, err := publicKey(db, contactID)
+	if err != nil {
+		return err
+	}
+	dataKey, err := crypto.DecodeDataKey(userKey)
+	if err != nil {
+		return err
+	}
+	sealed, err := crypto.SealToPublicKey(dataKey, pub)
+	if err != nil {
+		return err
+	}
+
+	var existing models.EmergencyContact
+	if err := db.Where("owner_id = ? AND contact_id = ?", ownerID, contactID).Limit(1).Find(&existing).Error; err != nil {
+		return err
+	}
+	if existing.ID != 0 {
+		return db.Model(&existing).Updates(map[string]interface{}{
+			"wrapped_key":  sealed,
+			"wait_days":    waitDays,
+			"requested_at": nil,
+			"grant_at":     nil,
+		}).Error
+	}
+
+	return db.Create(&models.EmergencyContact{
+		OwnerID:    ownerID,
+		ContactID:  contactID,
+		WrappedKey: sealed,
+		WaitDays:   waitDays,
+	}).Error
+}
+
+// RemoveEmergencyContact revokes a contact's emergency access.
+func RemoveEmergencyContact(db *gorm.DB, ownerID, contactID int64) error {
+	result := db.Unscoped().Where("owner_id = ? AND contact_id = ?", ownerID, contactID).
+		Delete(&models.EmergencyContact{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEmergencyNotFound
+	}
+	return nil
+}
+
+// EmergencyContacts lists the contacts the owner has nominated.
+func EmergencyContacts(db *gorm.DB, ownerID int64) ([]models.EmergencyContact, error) {
+	var contacts []models.EmergencyContact
+	err := db.Where("owner_id = ?", ownerID).Order("created_at ASC").Find(&contacts).Error
+	return contacts, err
+}
+
+// EmergencyGrants lists the owners who nominated the user as their contact.
+func EmergencyGrants(db *gorm.DB, contactID int64) ([]models.EmergencyContact, error) {
+	var grants []models.EmergencyContact
+	err := db.Where("contact_id = ?", contactID).Order("created_at ASC").Find(&grants).Error
+	return grants, err
+}
+
+// RequestEmergencyAccess starts the owner's waiting period for the contact.
+// A request already in progress or granted is returned unchanged.
+func RequestEmergencyAccess(db *gorm.DB, contactID, ownerID int64, now time.Time) (*models.EmergencyContact, error) {
+	c, err := emergencyContact(db, ownerID, contactID)
+	if err != nil {
+		return nil, err
+	}
+	if EmergencyStatus(c, now) != EmergencyIdle {
+		return c, nil
+	}
+
+	grantAt := now.AddDate(0, 0, c.WaitDays)
+	if err := db.Model(c).Updates(map[string]interface{}{
+		"requested_at": now,
+		"grant_at":     grantAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+	c.RequestedAt, c.GrantAt = &now, &grantAt
+	return c, nil
+}
+
+// RejectEmergencyAccess cancels a request by its ID; only the owner may do this.
+// Access that was already granted is revoked as well.
+func RejectEmergencyAccess(db *gorm.DB, ownerID int64, id uint) (*models.EmergencyContact, error) {
+	var c models.EmergencyContact
+	err := db.Where("id = ? AND owner_id = ?", id, ownerID).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEmergencyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&c).Updates(map[string]interface{}{
+		"requested_at": nil,
+		"grant_at":     nil,
+	}).Error; err != nil {
+		return nil, err
+	}
+	c.RequestedAt, c.GrantAt = nil, nil
+	return &c, nil
+}
+
+// OpenEmergencyVault returns the owner's session-encoded data key for a contact
+// whose request has been granted. userKey is the contact's own data key.
+func OpenEmergencyVault(db *gorm.DB, contactID, ownerID int64, userKey string, now time.Time) (string, error) {
+	c, err := emergencyContact(db, ownerID, contactID)
+	if err != nil {
+		return "", err
+	}
+	if EmergencyStatus(c, now) != EmergencyGranted {
+		return "", ErrEmergencyPending
+	}
+
+	var contact models.User
+	if err := db.Where("telegram_id = ?", contactID).First(&contact).Error; err != nil {
+		return "", err
+	}
+	priv, err := unwrapPrivateKey(contact.WrappedPrivateKey, userKey)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := crypto.OpenWithPrivateKey(c.WrappedKey, priv)
+	if err != nil {
+		return "", err
+	}
+	return crypto.EncodeDataKey(dataKey), nil
+}
+
+// emergencyContact loads the owner's nomination of contactID, or ErrEmergencyNotFound.
+func emergencyContact(db *gorm.DB, ownerID, contactID int64) (*models.EmergencyContact, error) {
+	var c models.EmergencyContact
+	err := db.Where("owner_id = ? AND contact_id = ?", ownerID, contactID).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEmergencyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// resealEmergencyKeys seals a new data key to each of the owner's contacts.
+// Contacts without a public key keep their old (now useless) copy.
+func resealEmergencyKeys(tx *gorm.DB, ownerID int64, dataKey []byte) error {
+	contacts, err := EmergencyContacts(tx, ownerID)
+	if err != nil {
+		return err
+	}
+	for i := range contacts {
+		pub, err := publicKey(tx, contacts[i].ContactID)
+		if err != nil {
+			if errors.Is(err, ErrNoPublicKey) {
+				continue
+			}
+			return err
+		}
+		sealed, err := crypto.SealToPublicKey(dataKey, pub)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&contacts[i]).Update("wrapped_key", sealed).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package vault
+
+import (
+	"time"
+
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultReminderLead is how long before expiry the first reminder is sent.
+const DefaultReminderLead = 7 * 24 * time.Hour
+
+// ExpiryState classifies an entry's rotation deadline.
+type ExpiryState int
+
+// Expiry states.
+const (
+	ExpiryNone    ExpiryState = iota // No deadline set
+	ExpiryOK                         // Deadline further away than the reminder lead
+	ExpiryDueSoon                    // Within the reminder lead
+	ExpiryOverdue                    // Deadline passed
+)
+
+// EntryExpiry classifies entry's deadline at now.
+func EntryExpiry(entry *models.PasswordEntry, now time.Time, lead time.Duration) ExpiryState {
+	switch {
+	case entry.ExpiresAt == nil:
+		return ExpiryNone
+	case !now.Before(*entry.ExpiresAt):
+		return ExpiryOverdue
+	case now.Add(lead).After(*entry.ExpiresAt):
+		return ExpiryDueSoon
+	}
+	return ExpiryOK
+}
+
+// SetRotation makes the entry expire every days after a password change,
+// starting from now. days <= 0 removes both the rotation and any deadline.
+func SetRotation(db *gorm.DB, entryID uint, days int, now time.Time) error {
+	var expiresAt *time.Time
+	if days > 0 {
+		t := now.AddDate(0, 0, days)
+		expiresAt = &t
+	} else {
+		days = 0
+	}
+	return setExpiry(db, entryID, days, expiresAt)
+}
+
+// SetExpiry sets a one-off deadline (nil clears it) and turns rotation off.
+func SetExpiry(db *gorm.DB, entryID uint, at *time.Time) error {
+	return setExpiry(db, entryID, 0, at)
+}
+
+func setExpiry(db *gorm.DB, entryID uint, days int, at *time.Time) error {
+	return db.Model(&models.PasswordEntry{}).Where("id = ?", entryID).Updates(map[string]interface{}{
+		"rotation_days": days,
+		"expires_at":    at,
+		"reminded_at":   nil,
+	}).Error
+}
+
+// DueReminders returns personal entries of all users that need a reminder at now:
+// those entering the lead window that were not reminded yet, and overdue
+// ones not reminded since their deadline passed.
+func DueReminders(db *gorm.DB, now time.Time, lead time.Duration) ([]models.PasswordEntry, error) {
+	var entries []models.PasswordEntry
+	err := db.Where("vault_id IS NULL").
+		Where("expires_at IS NOT NULL AND expires_at <= ?", now.Add(lead)).
+		Where("reminded_at IS NULL OR (expires_at <= ? AND reminded_at < expires_at)", now).
+		Order("user_id, expires_at").
+		Find(&entries).Error
+	return entries, err
+}
+
+// MarkReminded records that a reminder for the entry's current deadline was sent.
+func MarkReminded(db *gorm.DB, entryID uint, now time.Time) error {
+	return db.Model(&models.PasswordEntry{}).Where("id = ?", entryID).Update("reminded_at", now).Error
+}
+
+// rearmExpiry carries rotation metadata over an upsert and, when the password
+// changed on a rotating entry, starts a new period.
+func rearmExpiry(entry, current *models.PasswordEntry, passwordChanged bool, now time.Time) {
+	entry.RotationDays = current.RotationDays
+	entry.ExpiresAt = current.ExpiresAt
+	entry.RemindedAt = current.RemindedAt
+
+	if passwordChanged && current.RotationDays > 0 {
+		t := now.AddDate(0, 0, current.RotationDays)
+		entry.ExpiresAt = &t
+		entry.RemindedAt = nil
+	}
+}
@@ -7,11 +7,18 @@ import (
 )
 
 // GetEntry retrieves a single password entry by service name.
-func GetEntry(db *gorm.DB, userID int64, service string) (*models.PasswordEntry, error) {
+func GetEntry(db *gorm.DB, scope Scope, service string) (*models.PasswordEntry, error) {
 	var entry models.PasswordEntry
-	err := db.Where("user_id = ? AND service = ?", userID, service).First(&entry).Error
+	err := scope.where(db).Where("service = ?", service).First(&entry).Error
 	if err != nil {
 		return nil, err
 	}
 	return &entry, nil
 }
+
+// GetEntries retrieves the scope's entries with the given service names, in no particular order.
+func GetEntries(db *gorm.DB, scope Scope, services []string) ([]models.PasswordEntry, error) {
+	var entries []models.PasswordEntry
+	err := scope.where(db).Where("service IN ?", services).Find(&entries).Error
+	return entries, err
+}
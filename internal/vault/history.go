@@ -0,0 +1,154 @@
+package vault
+
+import (
+	"errors"
+	"time"
+
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultHistoryRetention is the number of versions kept when the user has no preference.
+const DefaultHistoryRetention = 10
+
+// Predefined history errors
+var (
+	// ErrServiceExists is returned when renaming onto an existing service name.
+	ErrServiceExists = errors.New("service already exists")
+	// ErrVersionUnreadable is returned when restoring a version that does not
+	// open with the current key, e.g. one a key rotation could not carry over.
+	ErrVersionUnreadable = errors.New("version does not open with the current key")
+)
+
+// recordVersion stores the entry's current ciphertext as a version and prunes
+// versions beyond the user's retention count. Consecutive identical ciphertexts
+// (e.g. rename followed by an edit) are stored once.
+func recordVersion(tx *gorm.DB, entry *models.PasswordEntry) error {
+	var latest models.PasswordEntryVersion
+	if err := tx.Where("entry_id = ?", entry.ID).Order("id DESC").Limit(1).Find(&latest).Error; err != nil {
+		return err
+	}
+	if latest.ID != 0 && latest.EncryptedData == entry.EncryptedData {
+		return nil
+	}
+
+	version := models.PasswordEntryVersion{
+		EntryID:       entry.ID,
+		UserID:        entry.UserID,
+		Service:       entry.Service,
+		EncryptedData: entry.EncryptedData,
+	}
+	if err := tx.Create(&version).Error; err != nil {
+		return err
+	}
+
+	return pruneVersions(tx, entry.UserID, entry.ID)
+}
+
+// pruneVersions hard-deletes all but the newest versions of an entry.
+func pruneVersions(tx *gorm.DB, userID int64, entryID uint) error {
+	keep := historyRetention(tx, userID)
+
+	newest := tx.Model(&models.PasswordEntryVersion{}).Select("id").
+		Where("entry_id = ?", entryID).Order("id DESC").Limit(keep)
+
+	return tx.Where("entry_id = ? AND id NOT IN (?)", entryID, newest).
+		Delete(&models.PasswordEntryVersion{}).Error
+}
+
+// historyRetention returns the user's version retention count.
+func historyRetention(db *gorm.DB, userID int64) int {
+	var user models.User
+	if err := db.Where("telegram_id = ?", userID).Limit(1).Find(&user).Error; err != nil || user.ID == 0 {
+		return DefaultHistoryRetention
+	}
+	if user.HistoryRetention <= 0 {
+		return DefaultHistoryRetention
+	}
+	return user.HistoryRetention
+}
+
+// EntryHistory finds an entry in the scope (same matching as retrieval) and
+// returns its versions, newest first.
+func EntryHistory(db *gorm.DB, scope Scope, service string) (*models.PasswordEntry, []models.PasswordEntryVersion, error) {
+	entry, err := FindEntry(db, scope, service)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var versions []models.PasswordEntryVersion
+	err = db.Where("entry_id = ? AND user_id = ?", entry.ID, entry.UserID).Order("id DESC").Find(&versions).Error
+	return entry, versions, err
+}
+
+// RestoreVersion makes a version the entry's current ciphertext. The version
+// must belong to an entry in the scope and open with userKey, the scope's
+// current key; anything else is refused with ErrVersionUnreadable rather than
+// leaving an entry that can no longer be read. The replaced ciphertext is
+// itself kept as a version, so a restore can be undone.
+func RestoreVersion(db *gorm.DB, scope Scope, versionID uint, userKey string) (*models.PasswordEntry, error) {
+	var entry models.PasswordEntry
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockKey(tx, scope, userKey); err != nil {
+			return err
+		}
+
+		var version models.PasswordEntryVersion
+		if err := tx.Where("id = ? AND user_id = ?", versionID, scope.UserID).First(&version).Error; err != nil {
+			return err
+		}
+		if err := scope.where(tx).Where("id = ?", version.EntryID).First(&entry).Error; err != nil {
+			return err
+		}
+
+		restored := entry
+		restored.EncryptedData = version.EncryptedData
+		cred, err := DecryptCredential(&restored, userKey)
+		if err != nil {
+			return ErrVersionUnreadable
+		}
+
+		if err := recordVersion(tx, &entry); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"encrypted_data": version.EncryptedData}
+		if current, err := DecryptCredential(&entry, userKey); err != nil || current.Password != cred.Password {
+			updates["password_changed_at"] = time.Now()
+		}
+		entry.EncryptedData = version.EncryptedData
+		return tx.Model(&entry).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// RenameEntry changes an entry's service name, keeping the pre-rename state as a version.
+func RenameEntry(db *gorm.DB, scope Scope, oldService, newService string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var entry models.PasswordEntry
+		if err := scope.where(tx).Where("service = ?", oldService).First(&entry).Error; err != nil {
+			return err
+		}
+
+		var taken int64
+		if err := scope.where(tx.Model(&models.PasswordEntry{})).
+			Where("service = ?", newService).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrServiceExists
+		}
+
+		if err := recordVersion(tx, &entry); err != nil {
+			return err
+		}
+
+		return tx.Model(&entry).Update("service", newService).Error
+	})
+}
@@ -0,0 +1,123 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoDataKey is returned when the user has not set a master passphrase yet.
+var ErrNoDataKey = errors.New("vault not initialized")
+
+// ErrStaleKey is returned when a write uses a key that a passphrase change or
+// member removal has since replaced. Unlocking again yields the current key.
+var ErrStaleKey = errors.New("key was rotated, unlock again")
+
+// keyDigest identifies a session-encoded key without revealing it.
+func keyDigest(key string) []byte {
+	sum := sha256.Sum256([]byte("passportier/key-digest/" + key))
+	return sum[:]
+}
+
+// lockKey takes a shared lock on the row that owns the scope's key (the user
+// or the team vault) for the rest of the transaction and checks that key is
+// still current. Rotations lock the same row FOR UPDATE, so a write either
+// finishes before a rotation reads the entries or sees the new digest and
+// fails with ErrStaleKey. Rows without a digest yet are not checked.
+func lockKey(tx *gorm.DB, scope Scope, key string) error {
+	var digest []byte
+	var err error
+	locked := tx.Clauses(clause.Locking{Strength: "SHARE"})
+	if scope.IsShared() {
+		var v models.Vault
+		err = locked.Select("key_digest").Where("id = ?", scope.VaultID).Take(&v).Error
+		digest = v.KeyDigest
+	} else {
+		var u models.User
+		err = locked.Select("key_digest").Where("telegram_id = ?", scope.UserID).Take(&u).Error
+		digest = u.KeyDigest
+	}
+	if err != nil {
+		return err
+	}
+	if len(digest) > 0 && !bytes.Equal(digest, keyDigest(key)) {
+		return ErrStaleKey
+	}
+	return nil
+}
+
+// RecordKeyDigest stores the digest of the user's data key if none is set
+// yet, enabling the stale key check for accounts created before it existed.
+func RecordKeyDigest(db *gorm.DB, userID int64, userKey string) error {
+	return db.Model(&models.User{}).Where("telegram_id = ? AND key_digest IS NULL", userID).
+		Update("key_digest", keyDigest(userKey)).Error
+}
+
+// LoadDataKey derives the key-encryption key from the passphrase once and
+// unwraps the user's data key. The GCM tag on the wrapped key verifies the passphrase.
+// Returns the session-encoded DEK, crypto.ErrInvalidPassword or ErrNoDataKey.
+func LoadDataKey(db *gorm.DB, userID int64, passphrase string) (string, error) {
+	var user models.User
+	err := db.Where("telegram_id = ?", userID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrNoDataKey
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if len(user.WrappedKey) == 0 {
+		return "", ErrNoDataKey
+	}
+
+	dataKey, err := crypto.UnwrapDataKey(user.WrappedKey, passphrase, user.Salt)
+	if err != nil {
+		return "", err
+	}
+
+	return crypto.EncodeDataKey(dataKey), nil
+}
+
+// InitDataKey sets the master passphrase: it generates a fresh salt and DEK,
+// wraps the DEK and saves the user. Returns the session-encoded DEK.
+func InitDataKey(db *gorm.DB, userID int64, passphrase string) (string, error) {
+	var user models.User
+	err := db.Where("telegram_id = ?", userID).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if len(user.WrappedKey) > 0 {
+		return "", errors.New("vault already initialized")
+	}
+
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := crypto.GenerateDataKey()
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := crypto.WrapDataKey(dataKey, passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	user.TelegramID = userID
+	user.Salt = salt
+	user.WrappedKey = wrapped
+	user.KeyDigest = keyDigest(crypto.EncodeDataKey(dataKey))
+	if err := db.Save(&user).Error; err != nil {
+		return "", err
+	}
+
+	return crypto.EncodeDataKey(dataKey), nil
+}
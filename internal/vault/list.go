@@ -1,14 +1,85 @@
 package vault
 
 import (
+	"sort"
+	"strings"
+
 	"passportier-bot/internal/models"
 
 	"gorm.io/gorm"
 )
 
-// ListEntries returns all password entries for a user (without decryption).
-func ListEntries(db *gorm.DB, userID int64) ([]models.PasswordEntry, error) {
+// Item is a listed entry with its decrypted credential.
+// Decrypted is false when the entry cannot be opened with the session key.
+type Item struct {
+	Entry      models.PasswordEntry
+	Credential models.Credential
+	Decrypted  bool
+}
+
+// FolderCount is a folder name with the number of entries it holds.
+type FolderCount struct {
+	Name  string
+	Count int
+}
+
+// ListEntries returns all password entries in the scope (without decryption).
+func ListEntries(db *gorm.DB, scope Scope) ([]models.PasswordEntry, error) {
 	var entries []models.PasswordEntry
-	result := db.Where("user_id = ?", userID).Order("service ASC").Find(&entries)
+	result := scope.where(db).Order("favorite DESC, service ASC").Find(&entries)
 	return entries, result.Error
 }
+
+// ListCredentials decrypts the scope's entries and keeps those matching the filter,
+// favorites first. Folder and tags may live inside the ciphertext, so filtering
+// happens after decryption; undecryptable entries only appear in unfiltered listings.
+func ListCredentials(db *gorm.DB, scope Scope, userKey string, filter Filter) ([]Item, error) {
+	entries, err := ListEntries(db, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(entries))
+	for i := range entries {
+		cred, err := DecryptCredential(&entries[i], userKey)
+		if err != nil {
+			if filter.IsEmpty() {
+				items = append(items, Item{Entry: entries[i]})
+			}
+			continue
+		}
+		if !filter.Match(cred) {
+			continue
+		}
+		items = append(items, Item{Entry: entries[i], Credential: cred, Decrypted: true})
+	}
+
+	return items, nil
+}
+
+// Folders returns the distinct folders among items, sorted by name.
+// Entries without a folder are not counted.
+func Folders(items []Item) []FolderCount {
+	counts := make(map[string]*FolderCount)
+	for _, it := range items {
+		name := it.Credential.Folder
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		if fc, ok := counts[key]; ok {
+			fc.Count++
+			continue
+		}
+		counts[key] = &FolderCount{Name: name, Count: 1}
+	}
+
+	folders := make([]FolderCount, 0, len(counts))
+	for _, fc := range counts {
+		folders = append(folders, *fc)
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		return strings.ToLower(folders[i].Name) < strings.ToLower(folders[j].Name)
+	})
+	return folders
+}
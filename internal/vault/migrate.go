@@ -0,0 +1,94 @@
+package vault
+
+import (
+	"log"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MigrateLegacyEntries re-encrypts the user's legacy (per-entry Argon2id) blobs
+// under the data key: entries and their history versions, including the
+// duplicates migration 002 moved into history. Blobs that fail to decrypt with
+// the passphrase are left untouched. Returns the number of migrated blobs.
+func MigrateLegacyEntries(db *gorm.DB, userID int64, passphrase string, userKey string) (int, error) {
+	entries, err := ListEntries(db, Personal(userID))
+	if err != nil {
+		return 0, err
+	}
+
+	var versions []models.PasswordEntryVersion
+	if err := db.Where("user_id = ?", userID).Find(&versions).Error; err != nil {
+		return 0, err
+	}
+
+	cm := crypto.NewCryptoManager()
+	migrated := 0
+
+	for _, entry := range entries {
+		ok, err := migrateLegacyBlob(db, cm, &models.PasswordEntry{}, entry.ID, entry.EncryptedData, passphrase, userKey)
+		if err != nil {
+			return migrated, err
+		}
+		if ok {
+			migrated++
+		}
+	}
+
+	for _, v := range versions {
+		ok, err := migrateLegacyBlob(db, cm, &models.PasswordEntryVersion{}, v.ID, v.EncryptedData, passphrase, userKey)
+		if err != nil {
+			return migrated, err
+		}
+		if ok {
+			migrated++
+		}
+	}
+
+	return migrated, nil
+}
+
+// migrateLegacyBlob re-encrypts one legacy encrypted_data value of model row id.
+// It reports false, without error, for blobs that are not legacy or do not open.
+func migrateLegacyBlob(db *gorm.DB, cm *crypto.CryptoManager, model interface{}, id uint, blob, passphrase, userKey string) (bool, error) {
+	if !crypto.IsLegacy(blob) {
+		return false, nil
+	}
+
+	plaintext, err := cm.DecryptLegacy(blob, passphrase)
+	if err != nil {
+		log.Printf("[VAULT] Legacy blob %T %d not migrated: %v", model, id, err)
+		return false, nil
+	}
+
+	encrypted, err := cm.Encrypt(plaintext, userKey)
+	if err != nil {
+		return false, err
+	}
+
+	if err := db.Model(model).Where("id = ?", id).UpdateColumn("encrypted_data", encrypted).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// VerifyLegacyPassphrase reports whether the passphrase opens the user's oldest
+// legacy entry. Each check is a full Argon2id derivation, so only one entry is
+// tried. hasLegacy is false when there is nothing to verify against.
+func VerifyLegacyPassphrase(db *gorm.DB, userID int64, passphrase string) (ok bool, hasLegacy bool, err error) {
+	var entries []models.PasswordEntry
+	err = Personal(userID).where(db).
+		Where("encrypted_data NOT LIKE ?", crypto.EnvelopePrefix+"%").
+		Order("id ASC").Limit(1).Find(&entries).Error
+	if err != nil || len(entries) == 0 {
+		return false, false, err
+	}
+
+	cm := crypto.NewCryptoManager()
+	if _, err := cm.DecryptLegacy(entries[0].EncryptedData, passphrase); err != nil {
+		return false, true, nil
+	}
+	return true, true, nil
+}
@@ -0,0 +1,127 @@
+package vault
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+	"time"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/storage"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB opens TEST_DATABASE_URL with the schema migrated, or skips.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := storage.MigrateUp(db); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	return db
+}
+
+// encryptLegacy builds a pre-envelope blob: base64(Salt + Nonce + Ciphertext).
+func encryptLegacy(t *testing.T, plaintext, passphrase string) string {
+	t.Helper()
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		t.Fatalf("GenerateSalt: %v", err)
+	}
+	sealed, err := crypto.Encrypt([]byte(plaintext), crypto.DeriveKey(passphrase, salt))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(append(salt, sealed...))
+}
+
+func TestMigrateLegacyEntries(t *testing.T) {
+	db := openTestDB(t)
+	userID := time.Now().UnixNano()
+	t.Cleanup(func() {
+		db.Unscoped().Where("user_id = ?", userID).Delete(&models.PasswordEntry{})
+		db.Where("user_id = ?", userID).Delete(&models.PasswordEntryVersion{})
+	})
+
+	cm := crypto.NewCryptoManager()
+	dataKey, err := crypto.GenerateDataKey()
+	if err != nil {
+		t.Fatalf("GenerateDataKey: %v", err)
+	}
+	userKey := crypto.EncodeDataKey(dataKey)
+	current, err := cm.Encrypt("already migrated", userKey)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	if ok, hasLegacy, err := VerifyLegacyPassphrase(db, userID, "correct horse"); err != nil || ok || hasLegacy {
+		t.Fatalf("empty vault: ok = %v, hasLegacy = %v, err = %v", ok, hasLegacy, err)
+	}
+
+	legacy := models.PasswordEntry{UserID: userID, Service: "github", EncryptedData: encryptLegacy(t, "hunter2", "correct horse")}
+	foreign := models.PasswordEntry{UserID: userID, Service: "gitlab", EncryptedData: encryptLegacy(t, "other", "wrong horse")}
+	migrated := models.PasswordEntry{UserID: userID, Service: "mail", EncryptedData: current}
+	for _, e := range []*models.PasswordEntry{&legacy, &foreign, &migrated} {
+		if err := db.Create(e).Error; err != nil {
+			t.Fatalf("create %s: %v", e.Service, err)
+		}
+	}
+	version := models.PasswordEntryVersion{EntryID: legacy.ID, UserID: userID, Service: "github", EncryptedData: encryptLegacy(t, "hunter1", "correct horse")}
+	if err := db.Create(&version).Error; err != nil {
+		t.Fatalf("create version: %v", err)
+	}
+
+	if ok, hasLegacy, err := VerifyLegacyPassphrase(db, userID, "correct horse"); err != nil || !ok || !hasLegacy {
+		t.Fatalf("right passphrase: ok = %v, hasLegacy = %v, err = %v", ok, hasLegacy, err)
+	}
+	if ok, hasLegacy, err := VerifyLegacyPassphrase(db, userID, "wrong passphrase"); err != nil || ok || !hasLegacy {
+		t.Fatalf("wrong passphrase: ok = %v, hasLegacy = %v, err = %v", ok, hasLegacy, err)
+	}
+
+	n, err := MigrateLegacyEntries(db, userID, "correct horse", userKey)
+	if err != nil {
+		t.Fatalf("MigrateLegacyEntries: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("migrated %d blobs, want the entry and its version", n)
+	}
+
+	reload := func(e *models.PasswordEntry) string {
+		t.Helper()
+		var got models.PasswordEntry
+		if err := db.First(&got, e.ID).Error; err != nil {
+			t.Fatalf("reload %s: %v", e.Service, err)
+		}
+		return got.EncryptedData
+	}
+
+	if got, err := cm.Decrypt(reload(&legacy), userKey); err != nil || got != "hunter2" {
+		t.Fatalf("migrated entry = %q, %v, want hunter2", got, err)
+	}
+	var v models.PasswordEntryVersion
+	if err := db.First(&v, version.ID).Error; err != nil {
+		t.Fatalf("reload version: %v", err)
+	}
+	if got, err := cm.Decrypt(v.EncryptedData, userKey); err != nil || got != "hunter1" {
+		t.Fatalf("migrated version = %q, %v, want hunter1", got, err)
+	}
+
+	// Blobs the passphrase does not open, and current ones, are left alone
+	if reload(&foreign) != foreign.EncryptedData {
+		t.Fatal("entry under another passphrase was rewritten")
+	}
+	if reload(&migrated) != current {
+		t.Fatal("envelope entry was rewritten")
+	}
+}
@@ -0,0 +1,156 @@
+package vault
+
+import (
+	"sort"
+	"strings"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Filter narrows a listing to one folder and/or tag. Matching is case-insensitive;
+// empty fields match everything.
+type Filter struct {
+	Folder string
+	Tag    string
+}
+
+// IsEmpty reports whether the filter matches every entry.
+func (f Filter) IsEmpty() bool {
+	return f.Folder == "" && f.Tag == ""
+}
+
+// Match reports whether a decrypted credential passes the filter.
+func (f Filter) Match(cred models.Credential) bool {
+	if f.Folder != "" && !strings.EqualFold(cred.Folder, f.Folder) {
+		return false
+	}
+	if f.Tag == "" {
+		return true
+	}
+	for _, t := range cred.Tags {
+		if strings.EqualFold(t, f.Tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTags trims, lowercases, de-duplicates and sorts tags.
+// Commas are separators, so "a, b" yields two tags.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, raw := range tags {
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			if t == "" || seen[t] {
+				continue
+			}
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SetFavorite pins or unpins an entry in the scope.
+func SetFavorite(db *gorm.DB, scope Scope, service string, favorite bool) error {
+	result := scope.where(db.Model(&models.PasswordEntry{})).
+		Where("service = ?", service).
+		Update("favorite", favorite)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetMetadataEncryption switches where the user's folders and tags are stored
+// and moves every existing entry to the new layout in one transaction.
+// Entries that cannot be decrypted are left untouched.
+func SetMetadataEncryption(db *gorm.DB, userID int64, encrypt bool, userKey string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := lockKey(tx, Personal(userID), userKey); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("telegram_id = ?", userID).
+			Update("encrypt_metadata", encrypt).Error; err != nil {
+			return err
+		}
+
+		var entries []models.PasswordEntry
+		if err := tx.Where("user_id = ?", userID).Find(&entries).Error; err != nil {
+			return err
+		}
+
+		cm := crypto.NewCryptoManager()
+		for i := range entries {
+			entry := &entries[i]
+			cred, err := DecryptCredential(entry, userKey)
+			if err != nil {
+				continue
+			}
+
+			folder, tags := applyMetadata(&cred, encrypt)
+			plainData, err := EncodeCredential(cred)
+			if err != nil {
+				return err
+			}
+			encrypted, err := cm.Encrypt(plainData, userKey)
+			if err != nil {
+				return err
+			}
+
+			// Moving metadata in or out of the ciphertext is not an edit
+			if err := tx.Model(entry).UpdateColumns(map[string]interface{}{
+				"encrypted_data": encrypted,
+				"folder":         folder,
+				"tags":           tags,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// encryptsMetadata reports whether the user keeps folder and tags in the ciphertext.
+func encryptsMetadata(db *gorm.DB, userID int64) bool {
+	var u models.User
+	if err := db.Select("encrypt_metadata").Where("telegram_id = ?", userID).Limit(1).Find(&u).Error; err != nil {
+		return false
+	}
+	return u.EncryptMetadata
+}
+
+// applyMetadata normalizes the credential's folder and tags and returns the
+// plaintext column values. When metadata is not encrypted it is moved out of
+// the credential so the ciphertext carries only secrets.
+func applyMetadata(cred *models.Credential, encrypt bool) (folder, tags string) {
+	cred.Folder = strings.TrimSpace(cred.Folder)
+	cred.Tags = NormalizeTags(cred.Tags)
+	if encrypt {
+		return "", ""
+	}
+
+	folder, tags = cred.Folder, strings.Join(cred.Tags, ",")
+	cred.Folder, cred.Tags = "", nil
+	return folder, tags
+}
+
+// mergeMetadata fills folder and tags from plaintext columns when the
+// ciphertext does not carry them.
+func mergeMetadata(entry *models.PasswordEntry, cred *models.Credential) {
+	if cred.Folder == "" {
+		cred.Folder = entry.Folder
+	}
+	if len(cred.Tags) == 0 && entry.Tags != "" {
+		cred.Tags = NormalizeTags([]string{entry.Tags})
+	}
+}
@@ -0,0 +1,223 @@
+package vault
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"strings"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeCount is the number of codes issued at a time.
+const RecoveryCodeCount = 10
+
+// recoveryCodeBytes is the entropy of a code (80 bits, 16 base32 characters).
+const recoveryCodeBytes = 10
+
+// ErrInvalidRecoveryCode is returned for an unknown or already used code.
+var ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+
+// recoveryEncoding is base32 without padding; codes are shown in groups of four.
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateRecoveryCodes issues a fresh set of one-time codes, replacing any
+// previous ones. The codes wrap a new recovery key, which in turn wraps the data
+// key, so a passphrase change only has to re-wrap the recovery key.
+// The returned codes are shown once and never stored.
+func GenerateRecoveryCodes(db *gorm.DB, userID int64, userKey string) ([]string, error) {
+	dataKey, err := crypto.DecodeDataKey(userKey)
+	if err != nil {
+		return nil, err
+	}
+	recoveryKey, err := crypto.GenerateDataKey()
+	if err != nil {
+		return nil, err
+	}
+	sealedRecovery, err := crypto.Encrypt(recoveryKey, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	wrappedData, err := crypto.Encrypt(dataKey, recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	rows := make([]models.RecoveryCode, RecoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, err
+		}
+		salt, err := crypto.GenerateSalt()
+		if err != nil {
+			return nil, err
+		}
+		normalized := NormalizeRecoveryCode(codes[i])
+		wrapped, err := crypto.WrapDataKey(recoveryKey, normalized, salt)
+		if err != nil {
+			return nil, err
+		}
+		rows[i] = models.RecoveryCode{
+			UserID:     userID,
+			CodeHash:   recoveryCodeHash(normalized),
+			Salt:       salt,
+			WrappedKey: wrapped,
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("telegram_id = ?", userID).Updates(map[string]interface{}{
+			"recovery_key":         sealedRecovery,
+			"recovery_wrapped_key": wrappedData,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RecoverWithCode sets a new passphrase using a recovery code: the data key is
+// recovered through the code and wrapped under the new passphrase, and the code
+// is deleted. Entries stay encrypted under the same data key.
+// Returns the session-encoded DEK or ErrInvalidRecoveryCode.
+func RecoverWithCode(db *gorm.DB, userID int64, code, newPassphrase string) (string, error) {
+	normalized := NormalizeRecoveryCode(code)
+
+	var row models.RecoveryCode
+	if err := db.Where("user_id = ? AND code_hash = ?", userID, recoveryCodeHash(normalized)).
+		Limit(1).Find(&row).Error; err != nil {
+		return "", err
+	}
+	if row.ID == 0 {
+		return "", ErrInvalidRecoveryCode
+	}
+
+	var user models.User
+	if err := db.Where("telegram_id = ?", userID).First(&user).Error; err != nil {
+		return "", err
+	}
+
+	recoveryKey, err := crypto.UnwrapDataKey(row.WrappedKey, normalized, row.Salt)
+	if err != nil {
+		return "", ErrInvalidRecoveryCode
+	}
+	dataKey, err := crypto.Decrypt(user.RecoveryWrappedKey, recoveryKey)
+	if err != nil {
+		return "", ErrInvalidRecoveryCode
+	}
+
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := crypto.WrapDataKey(dataKey, newPassphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Deleting first makes concurrent use of the same code fail
+		result := tx.Delete(&models.RecoveryCode{}, row.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidRecoveryCode
+		}
+		return tx.Model(&models.User{}).Where("telegram_id = ?", userID).
+			Updates(map[string]interface{}{"salt": salt, "wrapped_key": wrapped}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return crypto.EncodeDataKey(dataKey), nil
+}
+
+// RemainingRecoveryCodes returns how many unused codes the user has.
+func RemainingRecoveryCodes(db *gorm.DB, userID int64) (int64, error) {
+	var n int64
+	err := db.Model(&models.RecoveryCode{}).Where("user_id = ?", userID).Count(&n).Error
+	return n, err
+}
+
+// NormalizeRecoveryCode uppercases a code and drops separators and spaces.
+func NormalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '-' || r == ' ':
+			return -1
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return r
+	}, code)
+}
+
+// newRecoveryCode returns a random code formatted as XXXX-XXXX-XXXX-XXXX.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := recoveryEncoding.EncodeToString(b)
+
+	groups := make([]string, 0, len(raw)/4)
+	for i := 0; i < len(raw); i += 4 {
+		groups = append(groups, raw[i:i+4])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// recoveryCodeHash is the lookup hash of a normalized code.
+func recoveryCodeHash(normalized string) []byte {
+	sum := sha256.Sum256([]byte(normalized))
+	return sum[:]
+}
+
+// rewrapRecoveryKey moves the recovery key to a new data key during a
+// passphrase change, so existing codes keep working. It adds the new columns
+// to updates; users without recovery codes are left as they are.
+func rewrapRecoveryKey(user *models.User, oldKey, newKey string, updates map[string]interface{}) error {
+	if len(user.RecoveryKey) == 0 {
+		return nil
+	}
+
+	oldData, err := crypto.DecodeDataKey(oldKey)
+	if err != nil {
+		return err
+	}
+	newData, err := crypto.DecodeDataKey(newKey)
+	if err != nil {
+		return err
+	}
+
+	recoveryKey, err := crypto.Decrypt(user.RecoveryKey, oldData)
+	if err != nil {
+		return err
+	}
+	sealedRecovery, err := crypto.Encrypt(recoveryKey, newData)
+	if err != nil {
+		return err
+	}
+	wrappedData, err := crypto.Encrypt(newData, recoveryKey)
+	if err != nil {
+		return err
+	}
+
+	updates["recovery_key"] = sealedRecovery
+	updates["recovery_wrapped_key"] = wrappedData
+	return nil
+}
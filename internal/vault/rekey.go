@@ -0,0 +1,167 @@
+package vault
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UndecryptableError lists entries that block a passphrase change.
+type UndecryptableError struct {
+	Services []string
+}
+
+func (e *UndecryptableError) Error() string {
+	return fmt.Sprintf("cannot decrypt %d entries: %s", len(e.Services), strings.Join(e.Services, ", "))
+}
+
+// ChangePassphrase verifies the old passphrase, rotates the data key and
+// re-encrypts every entry under it, then wraps the new key with the new passphrase.
+// Entries are loaded, decrypted and rewritten inside one transaction that holds
+// the user row FOR UPDATE. Saves lock that row FOR SHARE and check the key
+// digest before encrypting (see lockKey), so a save either completes before
+// the entries are read or fails with ErrStaleKey afterwards. If any entry
+// fails to decrypt, nothing is changed and an *UndecryptableError is returned.
+// Entry history, the team vault private key, emergency contact keys and the
+// recovery key follow the new key. Returns the new session-encoded DEK.
+func ChangePassphrase(db *gorm.DB, userID int64, oldPassphrase, newPassphrase string) (string, error) {
+	// The new wrapping does not depend on stored data, so its Argon2id
+	// derivation runs before any lock is taken
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := crypto.GenerateDataKey()
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := crypto.WrapDataKey(dataKey, newPassphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	newKey := crypto.EncodeDataKey(dataKey)
+
+	cm := crypto.NewCryptoManager()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		locked := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Session(&gorm.Session{})
+
+		// Locking the user row serializes concurrent passphrase changes
+		oldKey, err := LoadDataKey(locked, userID, oldPassphrase)
+		if err != nil {
+			return err
+		}
+
+		entries, err := ListEntries(locked, Personal(userID))
+		if err != nil {
+			return err
+		}
+
+		// Decrypt everything first so a single failure aborts before any write
+		plaintexts := make([]string, len(entries))
+		var failed []string
+		for i, entry := range entries {
+			plaintexts[i], err = decryptWithOldKey(cm, entry.EncryptedData, oldKey, oldPassphrase)
+			if err != nil {
+				failed = append(failed, entry.Service)
+			}
+		}
+		if len(failed) > 0 {
+			return &UndecryptableError{Services: failed}
+		}
+
+		for i, entry := range entries {
+			encrypted, err := cm.Encrypt(plaintexts[i], newKey)
+			if err != nil {
+				return err
+			}
+			// Re-encryption is not an edit: leave updated_at alone
+			if err := tx.Model(&models.PasswordEntry{}).Where("id = ?", entry.ID).
+				UpdateColumn("encrypted_data", encrypted).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := rekeyVersions(locked, cm, userID, oldKey, oldPassphrase, newKey); err != nil {
+			return err
+		}
+
+		// Emergency contacts must be able to open the new key
+		if err := resealEmergencyKeys(tx, userID, dataKey); err != nil {
+			return err
+		}
+
+		// The team vault private key and the recovery key follow the data key
+		var user models.User
+		if err := tx.Select("wrapped_private_key", "recovery_key").Where("telegram_id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{"salt": salt, "wrapped_key": wrapped, "key_digest": keyDigest(newKey)}
+		if len(user.WrappedPrivateKey) > 0 {
+			privateKey, err := unwrapPrivateKey(user.WrappedPrivateKey, oldKey)
+			if err != nil {
+				return err
+			}
+			rewrapped, err := wrapPrivateKey(privateKey, newKey)
+			if err != nil {
+				return err
+			}
+			updates["wrapped_private_key"] = rewrapped
+		}
+		if err := rewrapRecoveryKey(&user, oldKey, newKey, updates); err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("telegram_id = ?", userID).Updates(updates).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return newKey, nil
+}
+
+// rekeyVersions re-encrypts the user's entry history under newKey. Legacy
+// versions, including duplicates kept by migration 002, are opened with the
+// old passphrase. A version neither can open is left as it is and logged;
+// history is never dropped here.
+func rekeyVersions(tx *gorm.DB, cm *crypto.CryptoManager, userID int64, oldKey, oldPassphrase, newKey string) error {
+	var versions []models.PasswordEntryVersion
+	if err := tx.Where("user_id = ?", userID).Find(&versions).Error; err != nil {
+		return err
+	}
+
+	skipped := 0
+	for _, v := range versions {
+		plaintext, err := decryptWithOldKey(cm, v.EncryptedData, oldKey, oldPassphrase)
+		if err != nil {
+			skipped++
+			continue
+		}
+		encrypted, err := cm.Encrypt(plaintext, newKey)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PasswordEntryVersion{}).Where("id = ?", v.ID).
+			Update("encrypted_data", encrypted).Error; err != nil {
+			return err
+		}
+	}
+	if skipped > 0 {
+		log.Printf("[VAULT] User %d: %d history versions could not be decrypted and keep their old encryption", userID, skipped)
+	}
+	return nil
+}
+
+// decryptWithOldKey opens a blob written under the old data key or, for the
+// legacy format, under the old passphrase.
+func decryptWithOldKey(cm *crypto.CryptoManager, blob, oldKey, oldPassphrase string) (string, error) {
+	if crypto.IsLegacy(blob) {
+		return cm.DecryptLegacy(blob, oldPassphrase)
+	}
+	return cm.Decrypt(blob, oldKey)
+}
@@ -1,8 +1,6 @@
 package vault
 
 import (
-	"strings"
-
 	"passportier-bot/internal/crypto"
 	"passportier-bot/internal/models"
 
@@ -10,33 +8,34 @@ import (
 )
 
 // RetrieveCredential finds and decrypts the credential for the given service.
-// Returns the decrypted plaintext or crypto.ErrInvalidPassword if key is wrong.
-func RetrieveCredential(db *gorm.DB, userID int64, service string, userKey string) (string, error) {
-	entry, err := findEntry(db, userID, service)
+// Returns the matched entry and its credential, or crypto.ErrInvalidPassword if key is wrong.
+// An *AmbiguousError lists the candidates when the service name is not specific enough.
+func RetrieveCredential(db *gorm.DB, scope Scope, service string, userKey string) (*models.PasswordEntry, models.Credential, error) {
+	entry, err := FindEntry(db, scope, service)
 	if err != nil {
-		return "", err
+		return nil, models.Credential{}, err
 	}
 
-	cm := crypto.NewCryptoManager()
-	plaintext, err := cm.Decrypt(entry.EncryptedData, userKey)
+	cred, err := DecryptCredential(entry, userKey)
 	if err != nil {
-		// Zero-Knowledge: wrong password manifests as decryption failure
-		return "", err
+		return nil, models.Credential{}, err
 	}
 
-	return plaintext, nil
+	return entry, cred, nil
 }
 
-// findEntry queries the database for a matching credential entry.
-// Performs case-insensitive partial matching on the service name.
-func findEntry(db *gorm.DB, userID int64, service string) (*models.PasswordEntry, error) {
-	var entry models.PasswordEntry
-	serviceLower := "%" + strings.ToLower(service) + "%"
-
-	result := db.Where("user_id = ? AND LOWER(service) LIKE ?", userID, serviceLower).First(&entry)
-	if result.Error != nil {
-		return nil, result.Error
+// DecryptCredential decrypts an entry and parses its payload,
+// accepting both structured and legacy free-text records.
+// Plaintext folder and tags columns are merged into the result.
+func DecryptCredential(entry *models.PasswordEntry, userKey string) (models.Credential, error) {
+	cm := crypto.NewCryptoManager()
+	plaintext, err := cm.Decrypt(entry.EncryptedData, userKey)
+	if err != nil {
+		// Zero-Knowledge: wrong password manifests as decryption failure
+		return models.Credential{}, err
 	}
 
-	return &entry, nil
+	cred := ParseCredential(plaintext)
+	mergeMetadata(entry, &cred)
+	return cred, nil
 }
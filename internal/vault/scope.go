@@ -0,0 +1,60 @@
+package vault
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Scope selects whose entries a vault operation works on: a user's personal
+// vault or a shared team vault. Shared entries are stored with UserID 0, so
+// personal queries never see them.
+type Scope struct {
+	UserID  int64
+	VaultID uint
+}
+
+// Personal returns the scope of a user's own vault.
+func Personal(userID int64) Scope {
+	return Scope{UserID: userID}
+}
+
+// Shared returns the scope of a team vault.
+func Shared(vaultID uint) Scope {
+	return Scope{VaultID: vaultID}
+}
+
+// IsShared reports whether the scope is a team vault.
+func (s Scope) IsShared() bool {
+	return s.VaultID != 0
+}
+
+// where restricts a password_entries query to the scope.
+func (s Scope) where(db *gorm.DB) *gorm.DB {
+	if s.IsShared() {
+		return db.Where("vault_id = ?", s.VaultID)
+	}
+	return db.Where("user_id = ? AND vault_id IS NULL", s.UserID)
+}
+
+// conflict is the upsert target matching the scope's partial unique index.
+func (s Scope) conflict() clause.OnConflict {
+	if s.IsShared() {
+		return clause.OnConflict{
+			Columns:     []clause.Column{{Name: "vault_id"}, {Name: "service"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL AND vault_id IS NOT NULL"}}},
+		}
+	}
+	return clause.OnConflict{
+		Columns:     []clause.Column{{Name: "user_id"}, {Name: "service"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL AND vault_id IS NULL"}}},
+	}
+}
+
+// encryptsMetadata reports whether folder and tags are kept in the ciphertext.
+// Team vaults always encrypt them; personal vaults follow the user's setting.
+func (s Scope) encryptsMetadata(db *gorm.DB) bool {
+	if s.IsShared() {
+		return true
+	}
+	return encryptsMetadata(db, s.UserID)
+}
@@ -0,0 +1,67 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+
+	"passportier-bot/internal/models"
+	"passportier-bot/internal/search"
+
+	"gorm.io/gorm"
+)
+
+// AmbiguousError is returned when a lookup does not identify a single entry by
+// exact name or unique prefix. Candidates holds the exact service names of the
+// best matches, best first; a lone candidate is a "did you mean" suggestion.
+type AmbiguousError struct {
+	Query      string
+	Candidates []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%q matches %d entries: %s", e.Query, len(e.Candidates), strings.Join(e.Candidates, ", "))
+}
+
+// maxCandidates caps the choices offered for an ambiguous lookup.
+const maxCandidates = 8
+
+// SearchEntries ranks the scope's service names against query, best first.
+// Only service names are read, so no decryption takes place.
+func SearchEntries(db *gorm.DB, scope Scope, query string) ([]search.Match, error) {
+	var names []string
+	if err := scope.where(db.Model(&models.PasswordEntry{})).Pluck("service", &names).Error; err != nil {
+		return nil, err
+	}
+	return search.Rank(query, names), nil
+}
+
+// FindEntry resolves a user query to a single entry by exact name or unique
+// prefix (see search.Best). Returns gorm.ErrRecordNotFound when nothing matches
+// and *AmbiguousError when the query is only close to one or more entries.
+func FindEntry(db *gorm.DB, scope Scope, service string) (*models.PasswordEntry, error) {
+	matches, err := SearchEntries(db, scope, service)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	best, ok := search.Best(matches)
+	if !ok {
+		return nil, &AmbiguousError{Query: service, Candidates: topCandidates(matches)}
+	}
+	return GetEntry(db, scope, best.Name)
+}
+
+// topCandidates returns the names of the matches sharing the top kind.
+func topCandidates(matches []search.Match) []string {
+	var names []string
+	for _, m := range matches {
+		if m.Kind != matches[0].Kind || len(names) == maxCandidates {
+			break
+		}
+		names = append(names, m.Name)
+	}
+	return names
+}
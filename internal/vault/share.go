@@ -0,0 +1,137 @@
+package vault
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Share limits.
+const (
+	DefaultShareTTL   = time.Hour
+	MinShareTTL       = 5 * time.Minute
+	MaxShareTTL       = 7 * 24 * time.Hour
+	DefaultShareViews = 1
+	MaxShareViews     = 5
+)
+
+// Size of share identifiers and keys in random bytes.
+const (
+	shareIDBytes  = 16
+	shareKeyBytes = 32
+)
+
+// Predefined share errors
+var (
+	ErrShareNotFound = errors.New("share not found or expired")
+	ErrShareLimits   = errors.New("share TTL or view count out of range")
+)
+
+// SharePayload is the plaintext sealed into a share link.
+type SharePayload struct {
+	Service    string            `json:"service"`
+	Credential models.Credential `json:"credential"`
+}
+
+// CreateShare seals the credential under a fresh random key and stores only
+// the ciphertext. Returns the share ID and the base64url key for the URL fragment.
+func CreateShare(db *gorm.DB, userID int64, service string, cred models.Credential, ttl time.Duration, maxViews int) (id, key string, expiresAt time.Time, err error) {
+	if ttl < MinShareTTL || ttl > MaxShareTTL || maxViews < 1 || maxViews > MaxShareViews {
+		return "", "", time.Time{}, ErrShareLimits
+	}
+
+	// Organization metadata stays private, and the 2FA seed would let the
+	// recipient generate codes long after the link is gone
+	cred.Folder, cred.Tags, cred.TOTP = "", nil, ""
+	plain, err := json.Marshal(SharePayload{Service: service, Credential: cred})
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	idBytes := make([]byte, shareIDBytes)
+	keyBytes := make([]byte, shareKeyBytes)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", time.Time{}, err
+	}
+	if _, err := rand.Read(keyBytes); err != nil {
+		return "", "", time.Time{}, err
+	}
+	id = base64.RawURLEncoding.EncodeToString(idBytes)
+
+	// The ID is bound as additional data so ciphertexts cannot be swapped between links
+	sealed, err := crypto.EncryptWithAD(plain, keyBytes, []byte(id))
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	now := time.Now()
+	share := models.SharedSecret{
+		ID:         id,
+		UserID:     userID,
+		Ciphertext: sealed,
+		MaxViews:   maxViews,
+		ExpiresAt:  now.Add(ttl),
+	}
+	if err := db.Create(&share).Error; err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	// Opportunistic cleanup of links nobody opened
+	if err := PurgeExpiredShares(db, now); err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	return id, base64.RawURLEncoding.EncodeToString(keyBytes), share.ExpiresAt, nil
+}
+
+// OpenShare counts a view and returns the ciphertext and the views left.
+// The row is deleted on its last allowed view; expired rows are deleted and
+// reported as ErrShareNotFound.
+func OpenShare(db *gorm.DB, id string, now time.Time) (ciphertext []byte, viewsLeft int, err error) {
+	expired := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var share models.SharedSecret
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).Limit(1).Find(&share).Error; err != nil {
+			return err
+		}
+		if share.ID == "" {
+			return ErrShareNotFound
+		}
+
+		// Deleted in this (committed) transaction, reported after it
+		if !now.Before(share.ExpiresAt) {
+			expired = true
+			return tx.Delete(&share).Error
+		}
+
+		share.Views++
+		ciphertext = share.Ciphertext
+		viewsLeft = share.MaxViews - share.Views
+
+		if viewsLeft <= 0 {
+			return tx.Delete(&share).Error
+		}
+		return tx.Model(&share).Update("views", share.Views).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if expired {
+		return nil, 0, ErrShareNotFound
+	}
+	return ciphertext, viewsLeft, nil
+}
+
+// PurgeExpiredShares deletes all share links past their expiry.
+func PurgeExpiredShares(db *gorm.DB, now time.Time) error {
+	return db.Where("expires_at <= ?", now).Delete(&models.SharedSecret{}).Error
+}
@@ -0,0 +1,437 @@
+package vault
+
+import (
+	"errors"
+	"strings"
+
+	"passportier-bot/internal/crypto"
+	"passportier-bot/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Predefined team vault errors
+var (
+	ErrNotMember   = errors.New("not a member of this vault")
+	ErrForbidden   = errors.New("vault role does not allow this")
+	ErrNoPublicKey = errors.New("user has no key pair yet")
+	ErrInvalidRole = errors.New("invalid vault role")
+)
+
+// MaxVaultNameLength caps team vault names.
+const MaxVaultNameLength = 64
+
+// VaultInfo is a team vault together with the caller's role in it.
+type VaultInfo struct {
+	Vault models.Vault
+	Role  string
+}
+
+// ValidRole reports whether role can be granted to an invited member.
+func ValidRole(role string) bool {
+	return role == models.RoleEditor || role == models.RoleViewer
+}
+
+// CanWrite reports whether the role may add, change or delete entries.
+func CanWrite(role string) bool {
+	return role == models.RoleOwner || role == models.RoleEditor
+}
+
+// CanManage reports whether the role may invite and remove members.
+func CanManage(role string) bool {
+	return role == models.RoleOwner
+}
+
+// EnsureKeyPair gives the user an X25519 key pair if they have none yet.
+// The private key is wrapped under the user's data key, so it is only
+// usable while the vault is unlocked.
+func EnsureKeyPair(db *gorm.DB, userID int64, userKey string) error {
+	var user models.User
+	if err := db.Where("telegram_id = ?", userID).First(&user).Error; err != nil {
+		return err
+	}
+	if len(user.PublicKey) > 0 {
+		return nil
+	}
+
+	pub, priv, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	wrapped, err := wrapPrivateKey(priv, userKey)
+	if err != nil {
+		return err
+	}
+
+	return db.Model(&models.User{}).Where("telegram_id = ? AND public_key IS NULL", userID).
+		Updates(map[string]interface{}{"public_key": pub, "wrapped_private_key": wrapped}).Error
+}
+
+// wrapPrivateKey encrypts a private key under the session-encoded data key.
+func wrapPrivateKey(priv []byte, userKey string) ([]byte, error) {
+	dataKey, err := crypto.DecodeDataKey(userKey)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Encrypt(priv, dataKey)
+}
+
+// unwrapPrivateKey decrypts a private key wrapped by wrapPrivateKey.
+func unwrapPrivateKey(wrapped []byte, userKey string) ([]byte, error) {
+	dataKey, err := crypto.DecodeDataKey(userKey)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Decrypt(wrapped, dataKey)
+}
+
+// CreateTeamVault creates a vault owned by the user, with a fresh vault key
+// sealed to the owner's public key.
+func CreateTeamVault(db *gorm.DB, userID int64, userKey, name string) (*models.Vault, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > MaxVaultNameLength {
+		return nil, errors.New("invalid vault name")
+	}
+
+	if err := EnsureKeyPair(db, userID, userKey); err != nil {
+		return nil, err
+	}
+	pub, err := publicKey(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	vaultKey, err := crypto.GenerateDataKey()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := crypto.SealToPublicKey(vaultKey, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	v := models.Vault{Name: name, OwnerID: userID, KeyDigest: keyDigest(crypto.EncodeDataKey(vaultKey))}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&v).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.VaultMember{
+			VaultID:    v.ID,
+			UserID:     userID,
+			Role:       models.RoleOwner,
+			WrappedKey: sealed,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Membership returns the user's membership in a vault, or ErrNotMember.
+func Membership(db *gorm.DB, vaultID uint, userID int64) (*models.VaultMember, error) {
+	var m models.VaultMember
+	err := db.Where("vault_id = ? AND user_id = ?", vaultID, userID).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotMember
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// VaultKey opens the vault key sealed to the user and returns it session-encoded,
+// ready for the same Encrypt/Decrypt calls as a personal data key, with the user's role.
+func VaultKey(db *gorm.DB, vaultID uint, userID int64, userKey string) (string, string, error) {
+	m, err := Membership(db, vaultID, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	var user models.User
+	if err := db.Where("telegram_id = ?", userID).First(&user).Error; err != nil {
+		return "", "", err
+	}
+	priv, err := unwrapPrivateKey(user.WrappedPrivateKey, userKey)
+	if err != nil {
+		return "", "", err
+	}
+	vaultKey, err := crypto.OpenWithPrivateKey(m.WrappedKey, priv)
+	if err != nil {
+		return "", "", err
+	}
+	encoded := crypto.EncodeDataKey(vaultKey)
+
+	// Vaults created before key digests existed get one on first use
+	if err := db.Model(&models.Vault{}).Where("id = ? AND key_digest IS NULL", vaultID).
+		Update("key_digest", keyDigest(encoded)).Error; err != nil {
+		return "", "", err
+	}
+
+	return encoded, m.Role, nil
+}
+
+// AddMember invites a user to a vault or changes an existing member's role.
+// Only the owner may do this. The vault key is sealed to the invitee's public
+// key, so the invitee must have unlocked the bot at least once.
+func AddMember(db *gorm.DB, vaultID uint, ownerID int64, userKey string, inviteeID int64, role string) error {
+	if !ValidRole(role) {
+		return ErrInvalidRole
+	}
+
+	vaultKey, ownerRole, err := VaultKey(db, vaultID, ownerID, userKey)
+	if err != nil {
+		return err
+	}
+	if !CanManage(ownerRole) {
+		return ErrForbidden
+	}
+
+	existing, err := Membership(db, vaultID, inviteeID)
+	if err == nil {
+		if existing.Role == models.RoleOwner {
+			return ErrForbidden
+		}
+		return db.Model(existing).Update("role", role).Error
+	}
+	if !errors.Is(err, ErrNotMember) {
+		return err
+	}
+
+	pub, err := publicKey(db, inviteeID)
+	if err != nil {
+		return err
+	}
+	rawKey, err := crypto.DecodeDataKey(vaultKey)
+	if err != nil {
+		return err
+	}
+	sealed, err := crypto.SealToPublicKey(rawKey, pub)
+	if err != nil {
+		return err
+	}
+
+	return db.Create(&models.VaultMember{
+		VaultID:    vaultID,
+		UserID:     inviteeID,
+		Role:       role,
+		WrappedKey: sealed,
+	}).Error
+}
+
+// RemoveMember revokes a member's access: the owner may remove anyone but
+// themselves, and any other member may leave. The vault key is rotated, every
+// entry and version is re-encrypted and the new key is sealed to the remaining
+// members, so a key copied before removal opens nothing written afterwards or
+// stored now. All writes happen in one transaction.
+func RemoveMember(db *gorm.DB, vaultID uint, actorID int64, userKey string, memberID int64) error {
+	oldKey, actorRole, err := VaultKey(db, vaultID, actorID, userKey)
+	if err != nil {
+		return err
+	}
+	if actorID != memberID && !CanManage(actorRole) {
+		return ErrForbidden
+	}
+
+	target, err := Membership(db, vaultID, memberID)
+	if err != nil {
+		return err
+	}
+	if target.Role == models.RoleOwner {
+		return ErrForbidden
+	}
+
+	rawKey, err := crypto.GenerateDataKey()
+	if err != nil {
+		return err
+	}
+	newKey := crypto.EncodeDataKey(rawKey)
+	cm := crypto.NewCryptoManager()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Serializes with saves, which lock the vault row FOR SHARE (see lockKey)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.Vault{}).
+			Where("id = ?", vaultID).Update("key_digest", keyDigest(newKey)).Error; err != nil {
+			return err
+		}
+
+		var entries []models.PasswordEntry
+		if err := tx.Unscoped().Where("vault_id = ?", vaultID).Find(&entries).Error; err != nil {
+			return err
+		}
+		var failed []string
+		for _, entry := range entries {
+			plaintext, err := cm.Decrypt(entry.EncryptedData, oldKey)
+			if err != nil {
+				failed = append(failed, entry.Service)
+				continue
+			}
+			encrypted, err := cm.Encrypt(plaintext, newKey)
+			if err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.PasswordEntry{}).Where("id = ?", entry.ID).
+				UpdateColumn("encrypted_data", encrypted).Error; err != nil {
+				return err
+			}
+		}
+		if len(failed) > 0 {
+			return &UndecryptableError{Services: failed}
+		}
+
+		var versions []models.PasswordEntryVersion
+		if err := tx.Where("entry_id IN (?)", tx.Unscoped().Model(&models.PasswordEntry{}).
+			Select("id").Where("vault_id = ?", vaultID)).Find(&versions).Error; err != nil {
+			return err
+		}
+		for _, v := range versions {
+			plaintext, err := cm.Decrypt(v.EncryptedData, oldKey)
+			if err != nil {
+				if err := tx.Delete(&models.PasswordEntryVersion{}, v.ID).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			encrypted, err := cm.Encrypt(plaintext, newKey)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.PasswordEntryVersion{}).Where("id = ?", v.ID).
+				UpdateColumn("encrypted_data", encrypted).Error; err != nil {
+				return err
+			}
+		}
+
+		// Hard delete so the user can be invited again
+		if err := tx.Unscoped().Delete(target).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("telegram_id = ? AND active_vault_id = ?", memberID, vaultID).
+			Update("active_vault_id", nil).Error; err != nil {
+			return err
+		}
+
+		var remaining []models.VaultMember
+		if err := tx.Where("vault_id = ?", vaultID).Find(&remaining).Error; err != nil {
+			return err
+		}
+		for i := range remaining {
+			pub, err := publicKey(tx, remaining[i].UserID)
+			if err != nil {
+				return err
+			}
+			sealed, err := crypto.SealToPublicKey(rawKey, pub)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&remaining[i]).Update("wrapped_key", sealed).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UserVaults returns the team vaults the user belongs to, ordered by name.
+func UserVaults(db *gorm.DB, userID int64) ([]VaultInfo, error) {
+	var members []models.VaultMember
+	if err := db.Where("user_id = ?", userID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	roles := make(map[uint]string, len(members))
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		roles[m.VaultID] = m.Role
+		ids = append(ids, m.VaultID)
+	}
+
+	var vaults []models.Vault
+	if err := db.Where("id IN ?", ids).Order("name ASC").Find(&vaults).Error; err != nil {
+		return nil, err
+	}
+
+	infos := make([]VaultInfo, 0, len(vaults))
+	for _, v := range vaults {
+		infos = append(infos, VaultInfo{Vault: v, Role: roles[v.ID]})
+	}
+	return infos, nil
+}
+
+// Members lists a vault's members, owner first. The caller must be a member.
+func Members(db *gorm.DB, vaultID uint, userID int64) ([]models.VaultMember, error) {
+	if _, err := Membership(db, vaultID, userID); err != nil {
+		return nil, err
+	}
+
+	var members []models.VaultMember
+	err := db.Where("vault_id = ?", vaultID).
+		Order("CASE WHEN role = 'owner' THEN 0 ELSE 1 END, created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// SelectVault makes a team vault the user's active vault; 0 selects the personal vault.
+func SelectVault(db *gorm.DB, userID int64, vaultID uint) error {
+	var active interface{}
+	if vaultID != 0 {
+		if _, err := Membership(db, vaultID, userID); err != nil {
+			return err
+		}
+		active = vaultID
+	}
+	return db.Model(&models.User{}).Where("telegram_id = ?", userID).
+		Update("active_vault_id", active).Error
+}
+
+// ActiveVault returns the user's active team vault, or nil for the personal vault.
+// A vault the user no longer belongs to counts as personal.
+func ActiveVault(db *gorm.DB, userID int64) (*models.Vault, string, error) {
+	var user models.User
+	if err := db.Select("active_vault_id").Where("telegram_id = ?", userID).Limit(1).Find(&user).Error; err != nil {
+		return nil, "", err
+	}
+	if user.ActiveVaultID == nil {
+		return nil, "", nil
+	}
+
+	m, err := Membership(db, *user.ActiveVaultID, userID)
+	if errors.Is(err, ErrNotMember) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	v, err := GetVault(db, m.VaultID)
+	if err != nil {
+		return nil, "", err
+	}
+	return v, m.Role, nil
+}
+
+// GetVault returns a team vault by ID.
+func GetVault(db *gorm.DB, vaultID uint) (*models.Vault, error) {
+	var v models.Vault
+	if err := db.First(&v, vaultID).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// publicKey returns the user's public key, or ErrNoPublicKey.
+func publicKey(db *gorm.DB, userID int64) ([]byte, error) {
+	var user models.User
+	if err := db.Select("public_key").Where("telegram_id = ?", userID).Limit(1).Find(&user).Error; err != nil {
+		return nil, err
+	}
+	if len(user.PublicKey) == 0 {
+		return nil, ErrNoPublicKey
+	}
+	return user.PublicKey, nil
+}
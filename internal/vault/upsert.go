@@ -1,36 +1,107 @@
 package vault
 
 import (
+	"time"
+
 	"passportier-bot/internal/crypto"
 	"passportier-bot/internal/models"
+	"passportier-bot/internal/totp"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
-// UpsertCredential encrypts the data and upserts it into the database.
-// Uses CryptoManager for Zero-Knowledge encryption with embedded salt.
-func UpsertCredential(db *gorm.DB, userID int64, service string, plainData string, userKey string) error {
-	cm := crypto.NewCryptoManager()
-	encrypted, err := cm.Encrypt(plainData, userKey)
+// UpsertCredential serializes and encrypts the credential and upserts it into the database.
+// userKey is the session data key; the Argon2id derivation already happened at unlock.
+// The previous ciphertext, if any, is kept as a version.
+// A TOTP secret, if present, must be a base32 secret or otpauth:// URI.
+// Folder and tags go to plaintext columns unless the scope keeps metadata encrypted.
+// Changing the password records PasswordChangedAt and moves the deadline of a
+// rotating entry forward.
+// For a team vault userKey is the vault key. A key replaced by a concurrent
+// rotation is refused with ErrStaleKey.
+func UpsertCredential(db *gorm.DB, scope Scope, service string, cred models.Credential, userKey string) error {
+	if cred.TOTP != "" {
+		if _, err := totp.Parse(cred.TOTP); err != nil {
+			return err
+		}
+	}
+
+	folder, tags := applyMetadata(&cred, scope.encryptsMetadata(db))
+
+	plainData, err := EncodeCredential(cred)
 	if err != nil {
 		return err
 	}
 
-	entry := buildEntry(userID, service, encrypted)
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Encrypt only once the key is known to be current and cannot be
+		// rotated until this transaction ends
+		if err := lockKey(tx, scope, userKey); err != nil {
+			return err
+		}
+		encrypted, err := crypto.NewCryptoManager().Encrypt(plainData, userKey)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		entry := buildEntry(scope, service, encrypted)
+		entry.Folder = folder
+		entry.Tags = tags
+		entry.PasswordChangedAt = &now
 
-	// Upsert: conflict on (user_id, service) -> update encrypted_data
-	return db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "user_id"}, {Name: "service"}},
-		DoUpdates: clause.AssignmentColumns([]string{"encrypted_data", "updated_at"}),
-	}).Create(&entry).Error
+		// Keep the ciphertext being overwritten in the entry's history
+		var current models.PasswordEntry
+		if err := scope.where(tx).Where("service = ?", service).Limit(1).Find(&current).Error; err != nil {
+			return err
+		}
+		passwordChanged := true
+		if current.ID != 0 {
+			if err := recordVersion(tx, &current); err != nil {
+				return err
+			}
+
+			// A new password starts a new rotation period
+			previous, err := DecryptCredential(&current, userKey)
+			passwordChanged = err != nil || previous.Password != cred.Password
+			rearmExpiry(&entry, &current, passwordChanged, now)
+		}
+
+		// Upsert: conflict on (user_id, service) or (vault_id, service) -> update encrypted_data and metadata
+		columns := []string{"encrypted_data", "folder", "tags", "expires_at", "reminded_at", "updated_at"}
+		if passwordChanged {
+			columns = append(columns, "password_changed_at")
+		}
+		onConflict := scope.conflict()
+		onConflict.DoUpdates = clause.AssignmentColumns(columns)
+		return tx.Clauses(onConflict).Create(&entry).Error
+	})
+}
+
+// UpdateCredential saves cred under newService, first renaming the entry from
+// oldService when it differs. Both happen in one transaction, so a failed save
+// does not leave the entry renamed.
+func UpdateCredential(db *gorm.DB, scope Scope, oldService, newService string, cred models.Credential, userKey string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if oldService != "" && oldService != newService {
+			if err := RenameEntry(tx, scope, oldService, newService); err != nil {
+				return err
+			}
+		}
+		return UpsertCredential(tx, scope, newService, cred, userKey)
+	})
 }
 
 // buildEntry constructs a PasswordEntry model from the given parameters.
-func buildEntry(userID int64, service string, encrypted string) models.PasswordEntry {
-	return models.PasswordEntry{
-		UserID:        userID,
+func buildEntry(scope Scope, service string, encrypted string) models.PasswordEntry {
+	entry := models.PasswordEntry{
+		UserID:        scope.UserID,
 		Service:       service,
 		EncryptedData: encrypted,
 	}
+	if scope.IsShared() {
+		entry.VaultID = &scope.VaultID
+	}
+	return entry
 }
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS password_entry_versions;
+DROP TABLE IF EXISTS password_entries;
+DROP TABLE IF EXISTS users;
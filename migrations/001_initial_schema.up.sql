@@ -0,0 +1,62 @@
+-- ============================================================================
+-- PassPortierBot: initial schema
+-- ============================================================================
+-- Matches the GORM models in internal/models. Written to be idempotent so
+-- databases previously created by AutoMigrate are adopted as-is.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+
+    -- Telegram user ID
+    telegram_id BIGINT NOT NULL,
+
+    -- Argon2id salt for the key-encryption key
+    salt BYTEA NOT NULL,
+
+    -- Data-encryption key wrapped under the passphrase-derived key
+    wrapped_key BYTEA,
+
+    session_ttl BIGINT DEFAULT 1800,
+    history_retention BIGINT DEFAULT 10
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS wrapped_key BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS history_retention BIGINT DEFAULT 10;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_telegram_id ON users (telegram_id);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS password_entries (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+
+    user_id BIGINT,
+
+    -- Service name (e.g. "instagram", "gmail", "wifi")
+    service TEXT,
+
+    -- "v2:" + base64(Nonce[12] + Ciphertext + AuthTag[16]), or a legacy
+    -- base64(Salt[16] + Nonce[12] + Ciphertext + AuthTag[16]) blob
+    encrypted_data TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_entries_user_id ON password_entries (user_id);
+CREATE INDEX IF NOT EXISTS idx_password_entries_deleted_at ON password_entries (deleted_at);
+
+CREATE TABLE IF NOT EXISTS password_entry_versions (
+    id BIGSERIAL PRIMARY KEY,
+    entry_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    service TEXT,
+    encrypted_data TEXT NOT NULL,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_entry_versions_entry_id ON password_entry_versions (entry_id);
+CREATE INDEX IF NOT EXISTS idx_password_entry_versions_user_id ON password_entry_versions (user_id);
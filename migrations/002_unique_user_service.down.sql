@@ -0,0 +1,2 @@
+-- Deduplicated rows stay soft-deleted; only the constraint is removed.
+DROP INDEX IF EXISTS idx_password_entries_user_service;
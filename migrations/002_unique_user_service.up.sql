@@ -0,0 +1,37 @@
+-- ============================================================================
+-- Unique (user_id, service) for live entries
+-- ============================================================================
+-- Enables ON CONFLICT (user_id, service) WHERE deleted_at IS NULL upserts.
+-- Existing duplicates are resolved first: the most recently updated row is
+-- kept, the others are copied into its version history and soft-deleted.
+-- ============================================================================
+
+WITH ranked AS (
+    SELECT id, user_id, service, encrypted_data,
+           first_value(id) OVER w AS keep_id,
+           row_number() OVER w AS rn
+    FROM password_entries
+    WHERE deleted_at IS NULL
+    WINDOW w AS (PARTITION BY user_id, service ORDER BY updated_at DESC NULLS LAST, id DESC)
+)
+INSERT INTO password_entry_versions (entry_id, user_id, service, encrypted_data, created_at)
+SELECT keep_id, user_id, service, encrypted_data, now()
+FROM ranked
+WHERE rn > 1;
+
+UPDATE password_entries
+SET deleted_at = now()
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (
+            PARTITION BY user_id, service ORDER BY updated_at DESC NULLS LAST, id DESC
+        ) AS rn
+        FROM password_entries
+        WHERE deleted_at IS NULL
+    ) dup
+    WHERE dup.rn > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_entries_user_service
+    ON password_entries (user_id, service)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- Encrypted metadata stays inside encrypted_data; only the plaintext columns are dropped.
+ALTER TABLE users DROP COLUMN IF EXISTS encrypt_metadata;
+
+ALTER TABLE password_entries DROP COLUMN IF EXISTS favorite;
+ALTER TABLE password_entries DROP COLUMN IF EXISTS tags;
+ALTER TABLE password_entries DROP COLUMN IF EXISTS folder;
@@ -0,0 +1,12 @@
+-- ============================================================================
+-- Folders, tags and favorites
+-- ============================================================================
+-- folder/tags hold plaintext metadata; users who opt into encrypted metadata
+-- keep them inside encrypted_data instead and leave these columns empty.
+-- ============================================================================
+
+ALTER TABLE password_entries ADD COLUMN IF NOT EXISTS folder TEXT NOT NULL DEFAULT '';
+ALTER TABLE password_entries ADD COLUMN IF NOT EXISTS tags TEXT NOT NULL DEFAULT '';
+ALTER TABLE password_entries ADD COLUMN IF NOT EXISTS favorite BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS encrypt_metadata BOOLEAN NOT NULL DEFAULT false;
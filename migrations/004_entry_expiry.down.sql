@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_password_entries_expires_at;
+
+ALTER TABLE password_entries DROP COLUMN IF EXISTS reminded_at;
+ALTER TABLE password_entries DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE password_entries DROP COLUMN IF EXISTS rotation_days;
@@ -0,0 +1,13 @@
+-- ============================================================================
+-- Expiry and rotation reminders
+-- ============================================================================
+-- Kept in plaintext so the reminder scheduler works without an unlocked vault.
+-- ============================================================================
+
+ALTER TABLE password_entries ADD COLUMN IF NOT EXISTS rotation_days BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE password_entries ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE password_entries ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_password_entries_expires_at
+    ON password_entries (expires_at)
+    WHERE expires_at IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,19 @@
+-- ============================================================================
+-- One-time share links
+-- ============================================================================
+-- Only ciphertext is stored; the key lives in the link's URL fragment.
+-- Rows are deleted on their last allowed view or after expires_at.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS shared_secrets (
+    id TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    ciphertext BYTEA NOT NULL,
+    max_views BIGINT NOT NULL DEFAULT 1,
+    views BIGINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_shared_secrets_user_id ON shared_secrets (user_id);
+CREATE INDEX IF NOT EXISTS idx_shared_secrets_expires_at ON shared_secrets (expires_at);
@@ -0,0 +1,19 @@
+-- Shared entries (vault_id IS NOT NULL) would collide under the personal index; remove them first.
+DELETE FROM password_entry_versions
+WHERE entry_id IN (SELECT id FROM password_entries WHERE vault_id IS NOT NULL);
+DELETE FROM password_entries WHERE vault_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_password_entries_vault_service;
+DROP INDEX IF EXISTS idx_password_entries_user_service;
+CREATE UNIQUE INDEX idx_password_entries_user_service
+    ON password_entries (user_id, service)
+    WHERE deleted_at IS NULL;
+
+ALTER TABLE password_entries DROP COLUMN IF EXISTS vault_id;
+
+ALTER TABLE users DROP COLUMN IF EXISTS active_vault_id;
+ALTER TABLE users DROP COLUMN IF EXISTS wrapped_private_key;
+ALTER TABLE users DROP COLUMN IF EXISTS public_key;
+
+DROP TABLE IF EXISTS vault_members;
+DROP TABLE IF EXISTS vaults;
@@ -0,0 +1,50 @@
+-- ============================================================================
+-- Shared team vaults
+-- ============================================================================
+-- A vault key is stored only sealed to each member's X25519 public key.
+-- Shared entries carry vault_id and user_id 0, so personal queries
+-- (user_id = <telegram id>) never see them.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS vaults (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    name TEXT NOT NULL,
+    owner_id BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_vaults_owner_id ON vaults (owner_id);
+CREATE INDEX IF NOT EXISTS idx_vaults_deleted_at ON vaults (deleted_at);
+
+CREATE TABLE IF NOT EXISTS vault_members (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    vault_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role TEXT NOT NULL,
+    wrapped_key BYTEA NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vault_members_vault_user ON vault_members (vault_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_vault_members_user_id ON vault_members (user_id);
+CREATE INDEX IF NOT EXISTS idx_vault_members_deleted_at ON vault_members (deleted_at);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS public_key BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS wrapped_private_key BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS active_vault_id BIGINT;
+
+ALTER TABLE password_entries ADD COLUMN IF NOT EXISTS vault_id BIGINT;
+
+-- Personal uniqueness now excludes shared rows; shared rows are unique per vault
+DROP INDEX IF EXISTS idx_password_entries_user_service;
+CREATE UNIQUE INDEX idx_password_entries_user_service
+    ON password_entries (user_id, service)
+    WHERE deleted_at IS NULL AND vault_id IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_entries_vault_service
+    ON password_entries (vault_id, service)
+    WHERE deleted_at IS NULL AND vault_id IS NOT NULL;
@@ -0,0 +1,24 @@
+-- ============================================================================
+-- Emergency access (trusted contacts)
+-- ============================================================================
+-- wrapped_key is the owner's data key sealed to the contact's public key.
+-- A pending request (requested_at set) is granted at grant_at unless the
+-- owner rejects it, which clears both columns.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS emergency_contacts (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    owner_id BIGINT NOT NULL,
+    contact_id BIGINT NOT NULL,
+    wrapped_key BYTEA NOT NULL,
+    wait_days BIGINT NOT NULL,
+    requested_at TIMESTAMPTZ,
+    grant_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_emergency_contacts_owner_contact ON emergency_contacts (owner_id, contact_id);
+CREATE INDEX IF NOT EXISTS idx_emergency_contacts_contact_id ON emergency_contacts (contact_id);
+CREATE INDEX IF NOT EXISTS idx_emergency_contacts_deleted_at ON emergency_contacts (deleted_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE users DROP COLUMN IF EXISTS recovery_wrapped_key;
+ALTER TABLE users DROP COLUMN IF EXISTS recovery_key;
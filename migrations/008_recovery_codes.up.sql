@@ -0,0 +1,22 @@
+-- ============================================================================
+-- Offline recovery codes
+-- ============================================================================
+-- users.recovery_key is a random recovery key encrypted under the data key and
+-- users.recovery_wrapped_key is the data key encrypted under the recovery key,
+-- so passphrase changes can re-wrap both without the codes.
+-- Each code wraps the recovery key; only a SHA-256 lookup hash is stored.
+-- ============================================================================
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_key BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_wrapped_key BYTEA;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    code_hash BYTEA NOT NULL,
+    salt BYTEA NOT NULL,
+    wrapped_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE vaults DROP COLUMN IF EXISTS key_digest;
+ALTER TABLE users DROP COLUMN IF EXISTS key_digest;
@@ -0,0 +1,11 @@
+-- ============================================================================
+-- Data key digests
+-- ============================================================================
+-- SHA-256 digest of the current personal data key / team vault key. Writes
+-- lock the owning row FOR SHARE and compare the digest with the key they are
+-- about to encrypt under, so a save racing a key rotation fails instead of
+-- landing under the retired key. NULL (set lazily on unlock) skips the check.
+-- ============================================================================
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS key_digest BYTEA;
+ALTER TABLE vaults ADD COLUMN IF NOT EXISTS key_digest BYTEA;
@@ -0,0 +1 @@
+ALTER TABLE password_entries DROP COLUMN IF EXISTS password_changed_at;
@@ -0,0 +1,17 @@
+-- ============================================================================
+-- Password change time
+-- ============================================================================
+-- updated_at moves on any edit (notes, folder, favorite, re-encryption), so
+-- staleness is measured from the last change of the password itself. Existing
+-- rows are backfilled from history: the newest version marks the last save,
+-- and entries that were never edited keep their creation time.
+-- ============================================================================
+
+ALTER TABLE password_entries ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMPTZ;
+
+UPDATE password_entries e
+SET password_changed_at = COALESCE(
+    (SELECT MAX(v.created_at) FROM password_entry_versions v WHERE v.entry_id = e.id),
+    e.created_at
+)
+WHERE password_changed_at IS NULL;
@@ -0,0 +1,12 @@
+// Package migrations embeds the versioned SQL schema migrations.
+//
+// Files are named NNN_description.up.sql / NNN_description.down.sql and are
+// applied in version order by storage.MigrateUp.
+package migrations
+
+import "embed"
+
+// FS holds all migration files.
+//
+//go:embed *.sql
+var FS embed.FS